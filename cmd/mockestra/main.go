@@ -0,0 +1,114 @@
+// Command mockestra provides stack tooling that runs outside of tests.
+//
+//	mockestra lock [-f mockestra.lock] <tag>=<image> ...
+//	mockestra reap [-prefix name] [-run id] [-all]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/narwhl/mockestra"
+)
+
+const usage = `usage: mockestra <command> [arguments]
+
+commands:
+  lock    pin module images by digest in the lockfile
+  reap    remove containers and networks left behind by dead runs
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "lock":
+		err = lock(os.Args[2:])
+	case "reap":
+		err = reap(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// lock resolves the digest of each <tag>=<image> argument and merges it into
+// the lockfile, replacing the previous entry of the module.
+func lock(args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ExitOnError)
+	path := flags.String("f", mockestra.LockfileName, "path of the lockfile")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: mockestra lock [-f mockestra.lock] <tag>=<image> ...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	images := make(map[string]string)
+	for _, arg := range flags.Args() {
+		tag, image, ok := strings.Cut(arg, "=")
+		if !ok || tag == "" || image == "" {
+			return fmt.Errorf("invalid argument %q, expected <tag>=<image>", arg)
+		}
+		images[tag] = image
+	}
+
+	lockfile, err := mockestra.LoadLockfile(*path)
+	if errors.Is(err, fs.ErrNotExist) {
+		lockfile = make(mockestra.Lockfile)
+	} else if err != nil {
+		return err
+	}
+	resolved, err := mockestra.Lock(context.Background(), nil, images)
+	if err != nil {
+		return err
+	}
+	for tag, pins := range resolved {
+		lockfile[tag] = pins
+	}
+	if err := lockfile.Save(*path); err != nil {
+		return err
+	}
+	for _, tag := range slices.Sorted(maps.Keys(resolved)) {
+		for image, dgst := range resolved[tag] {
+			fmt.Printf("%s\t%s\t%s\n", tag, image, dgst)
+		}
+	}
+	return nil
+}
+
+// reap removes the leftovers of dead runs matching the flags.
+func reap(args []string) error {
+	flags := flag.NewFlagSet("reap", flag.ExitOnError)
+	prefix := flags.String("prefix", "", "only reap the stack of this prefix")
+	runID := flags.String("run", "", "only reap the run of this ID")
+	all := flags.Bool("all", false, "also reap runs whose process is still alive")
+	flags.Parse(args)
+
+	reaped, err := mockestra.Reap(context.Background(), mockestra.ReapFilter{
+		Prefix:      *prefix,
+		RunID:       *runID,
+		IncludeLive: *all,
+	})
+	for _, name := range reaped {
+		fmt.Println(name)
+	}
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"time"
 
 	"github.com/docker/go-connections/nat"
@@ -103,10 +104,10 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 
 type ContainerParams struct {
 	fx.In
-	Lifecycle                fx.Lifecycle
-	PostgresContainerRequest *testcontainers.GenericContainerRequest `name:"postgres"`
-	PostgresContainer        testcontainers.Container                `name:"postgres"`
-	Request                  *testcontainers.GenericContainerRequest `name:"concourse"`
+	Lifecycle fx.Lifecycle
+	Database  mockestra.SQLDatabase                   `name:"postgres"`
+	Request   *testcontainers.GenericContainerRequest `name:"concourse"`
+	Secrets   *mockestra.SecretSource
 }
 
 type Result struct {
@@ -116,17 +117,19 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
-	postgresIP, err := p.PostgresContainer.ContainerIP(context.Background())
+	postgresHost, postgresPort, err := p.Database.Address(context.Background())
 	if err != nil {
-		return Result{}, fmt.Errorf("failed to get %s container IP: %w", postgres.ContainerPrettyName, err)
+		return Result{}, fmt.Errorf("failed to get database address: %w", err)
+	}
+	postgresUser, postgresPassword, err := mockestra.ProvisionDatabase(context.Background(), p.Database, p.Secrets, Tag, DatabaseName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to provision %s database: %w", ContainerPrettyName, err)
 	}
-	_, postgresPort := nat.SplitProtoPort(postgres.Port)
 
-	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		p.PostgresContainerRequest.Env["POSTGRES_USER"], // TODO: use database specific user instead of admin user
-		p.PostgresContainerRequest.Env["POSTGRES_PASSWORD"],
-		postgresIP,
-		postgresPort,
+	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+		postgresUser,
+		postgresPassword,
+		net.JoinHostPort(postgresHost, postgresPort),
 		DatabaseName,
 	)).Customize(p.Request); err != nil {
 		return Result{}, fmt.Errorf("failed to set postgres url: %w", err)
@@ -187,4 +190,5 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"concourse"`),
 		),
 	),
+	mockestra.DependsOn(Tag, postgres.Tag),
 )
@@ -55,6 +55,7 @@ func TestConcourseModule(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"17",
@@ -384,6 +385,7 @@ func TestConcourseModule_Proxy(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"17",
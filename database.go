@@ -0,0 +1,196 @@
+package mockestra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// SQLDatabaseName is the name of the SQLDatabase consumed by dependents
+// such as hydra, kratos, zitadel and concourse.
+const SQLDatabaseName = "postgres"
+
+// SQLDatabase is a Postgres compatible server that dependents connect to and
+// provision their databases on, regardless of the module or external service
+// backing it. Postgres compatible modules provide it named after their tag.
+type SQLDatabase interface {
+	// Address returns the host and port the server is reachable at from other containers.
+	Address(ctx context.Context) (host, port string, err error)
+	// AdminCredentials returns the superuser the server was set up with.
+	AdminCredentials() (username, password string)
+	// CreateRole creates a login role, or resets the password of an existing one.
+	CreateRole(ctx context.Context, name, password string) error
+	// CreateDatabase creates a database owned by owner, or hands an existing
+	// one over to owner. An empty owner leaves the database to the admin user.
+	CreateDatabase(ctx context.Context, name, owner string) error
+	// Databases returns the databases the server was set up with, the one of
+	// the admin user first, along with the login roles owning them.
+	Databases() []DatabaseLogin
+}
+
+// DatabaseLogin is a database along with the login role owning it. Username
+// is empty for a database left to the admin user, and Password for a role
+// created without one.
+type DatabaseLogin struct {
+	Database string
+	Username string
+	Password string
+}
+
+// PostgresDatabase implements SQLDatabase for the container of a Postgres
+// compatible module, connecting as its admin user.
+type PostgresDatabase struct {
+	Container testcontainers.Container
+	Port      nat.Port
+	Username  string
+	Password  string
+	// Database is the maintenance database statements are run from.
+	Database string
+	// ExtraDatabases are the databases created along with the server.
+	ExtraDatabases []DatabaseLogin
+}
+
+var _ SQLDatabase = (*PostgresDatabase)(nil)
+
+func (d *PostgresDatabase) Address(ctx context.Context) (string, string, error) {
+	endpoint, err := InternalEndpoint(ctx, d.Container, d.Port)
+	if err != nil {
+		return "", "", err
+	}
+	return net.SplitHostPort(endpoint)
+}
+
+func (d *PostgresDatabase) AdminCredentials() (string, string) {
+	return d.Username, d.Password
+}
+
+func (d *PostgresDatabase) Databases() []DatabaseLogin {
+	return append([]DatabaseLogin{{Database: d.Database, Username: d.Username, Password: d.Password}}, d.ExtraDatabases...)
+}
+
+func (d *PostgresDatabase) CreateRole(ctx context.Context, name, password string) error {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = $1)", name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up role %s: %w", name, err)
+	}
+	statement := "CREATE ROLE %s WITH LOGIN PASSWORD %s"
+	if exists {
+		statement = "ALTER ROLE %s WITH LOGIN PASSWORD %s"
+	}
+	if _, err := conn.Exec(ctx, fmt.Sprintf(statement, pgx.Identifier{name}.Sanitize(), QuoteLiteral(password))); err != nil {
+		return fmt.Errorf("failed to create role %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *PostgresDatabase) CreateDatabase(ctx context.Context, name, owner string) error {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT FROM pg_catalog.pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up database %s: %w", name, err)
+	}
+	var statement string
+	switch {
+	case exists && owner == "":
+		return nil
+	case exists:
+		statement = fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", pgx.Identifier{name}.Sanitize(), pgx.Identifier{owner}.Sanitize())
+	default:
+		statement = "CREATE DATABASE " + pgx.Identifier{name}.Sanitize()
+		if owner != "" {
+			statement += " OWNER " + pgx.Identifier{owner}.Sanitize()
+		}
+	}
+	if _, err := conn.Exec(ctx, statement); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// connect opens an admin connection through the host endpoint of the container.
+func (d *PostgresDatabase) connect(ctx context.Context) (*pgx.Conn, error) {
+	endpoint, err := d.Container.PortEndpoint(ctx, d.Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database endpoint: %w", err)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.Username, d.Password),
+		Host:     endpoint,
+		Path:     "/" + d.Database,
+		RawQuery: "sslmode=disable",
+	}
+	conn, err := pgx.Connect(ctx, dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database at %s: %w", endpoint, err)
+	}
+	return conn, nil
+}
+
+// ProvisionDatabase creates database name on db, owned by a dedicated login
+// role of the same name without any other privilege, and returns the role
+// credentials for the dependent module tag to connect with. The password is
+// the `{tag}.database_password` secret of secrets.
+func ProvisionDatabase(ctx context.Context, db SQLDatabase, secrets *SecretSource, tag, name string) (string, string, error) {
+	password, err := secrets.Secret(SecretName(tag, "database_password"), 16)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate %s database password: %w", tag, err)
+	}
+	if err := db.CreateRole(ctx, name, password); err != nil {
+		return "", "", err
+	}
+	if err := db.CreateDatabase(ctx, name, name); err != nil {
+		return "", "", err
+	}
+	return name, password, nil
+}
+
+// QuoteLiteral quotes s as an SQL string literal, for statements such as
+// CREATE ROLE that take no bind parameters.
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// UseSQLDatabase backs dependents with the SQLDatabase of module tag instead
+// of the postgres module, e.g. mockestra.UseSQLDatabase(timescaledb.Tag).
+// It also stands in for the postgres module when the stack is validated,
+// declaring that it depends on module tag, which Graph draws the edges of
+// dependents to.
+func UseSQLDatabase(tag string) fx.Option {
+	return fx.Options(
+		fx.Provide(
+			fx.Annotate(
+				func(db SQLDatabase) SQLDatabase {
+					return db
+				},
+				fx.ParamTags(fmt.Sprintf(`name:"%s"`, tag)),
+				fx.ResultTags(fmt.Sprintf(`name:"%s"`, SQLDatabaseName)),
+			),
+		),
+		fx.Supply(
+			fx.Annotate(
+				Requirement{Module: SQLDatabaseName},
+				fx.ResultTags(`group:"requirements"`),
+			),
+		),
+		DependsOn(SQLDatabaseName, tag),
+	)
+}
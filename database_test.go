@@ -0,0 +1,74 @@
+package mockestra_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestPostgresDatabase(t *testing.T) {
+	db := &mockestra.PostgresDatabase{
+		Container: &mockestra.ExternalContainer{
+			ServiceName:  "timescaledb",
+			Address:      "db.internal",
+			ServicePorts: map[nat.Port]string{"5432/tcp": "6543"},
+		},
+		Port:     "5432/tcp",
+		Username: "admin",
+		Password: "secret",
+		Database: "admin",
+		ExtraDatabases: []mockestra.DatabaseLogin{
+			{Database: "reports", Username: "reports_user", Password: "reports_pass"},
+		},
+	}
+	host, port, err := db.Address(t.Context())
+	if err != nil || host != "db.internal" || port != "6543" {
+		t.Errorf("expected db.internal:6543, got %s:%s, %v", host, port, err)
+	}
+	if username, password := db.AdminCredentials(); username != "admin" || password != "secret" {
+		t.Errorf("expected admin credentials, got %s:%s", username, password)
+	}
+	expected := []mockestra.DatabaseLogin{
+		{Database: "admin", Username: "admin", Password: "secret"},
+		{Database: "reports", Username: "reports_user", Password: "reports_pass"},
+	}
+	if databases := db.Databases(); !slices.Equal(databases, expected) {
+		t.Errorf("expected databases %v, got %v", expected, databases)
+	}
+}
+
+func TestUseSQLDatabase(t *testing.T) {
+	timescaledb := &mockestra.PostgresDatabase{Username: "tsdb"}
+	var p struct {
+		fx.In
+		Database mockestra.SQLDatabase `name:"postgres"`
+	}
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		testModule("timescaledb"),
+		testModule("hydra", "postgres"),
+		fx.Supply(
+			fx.Annotate(
+				timescaledb,
+				fx.As(new(mockestra.SQLDatabase)),
+				fx.ResultTags(`name:"timescaledb"`),
+			),
+			fx.Annotate("database-test", fx.ResultTags(`name:"prefix"`)),
+		),
+		fx.Options(mockestra.Versions(map[string]string{"timescaledb": "latest", "hydra": "latest"})...),
+		mockestra.UseSQLDatabase("timescaledb"),
+		mockestra.ValidateModule(),
+		fx.Populate(&p),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	if p.Database != timescaledb {
+		t.Errorf("expected dependents to get the timescaledb database, got %v", p.Database)
+	}
+}
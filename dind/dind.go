@@ -1,20 +1,24 @@
 package dind
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"go.uber.org/fx"
 )
 
 const (
-	Tag   = "dind"
-	Image = "docker"
-	Port  = "2375/tcp" // Non-TLS port (TLS uses 2376)
+	Tag     = "dind"
+	Image   = "docker"
+	Port    = "2375/tcp" // Non-TLS port (TLS uses 2376)
+	TLSPort = "2376/tcp"
 
 	ContainerPrettyName = "Docker-in-Docker"
 )
@@ -24,6 +28,7 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"dind_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"dind"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
@@ -31,7 +36,7 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		ContainerRequest: testcontainers.ContainerRequest{
 			Name:         fmt.Sprintf("mock-%s-%s", p.Prefix, Tag),
 			Image:        fmt.Sprintf("%s:%s-dind", Image, p.Version),
-			ExposedPorts: []string{Port, "2376/tcp"},
+			ExposedPorts: []string{Port, TLSPort},
 			Env: map[string]string{
 				"DOCKER_TLS_CERTDIR": "",
 			},
@@ -47,6 +52,12 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		}
 	}
 
+	if p.CA != nil {
+		if err := WithCA(p.CA).Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
@@ -58,6 +69,59 @@ func WithTLS(certDir string) testcontainers.CustomizeRequestOption {
 	}
 }
 
+// WithCA enables TLS with mutual authentication for the Docker daemon using
+// server and client certificates issued by ca. The daemon then only listens on TLSPort,
+// so Port is no longer exposed; the client key pair is placed under /certs/client for
+// use from within the container.
+func WithCA(ca *pki.CA) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		serverCert, err := ca.IssueServer(Tag, pki.ServerNames(req.Name, req.NetworkAliases)...)
+		if err != nil {
+			return fmt.Errorf("failed to issue %s server certificate: %w", ContainerPrettyName, err)
+		}
+		clientCert, err := ca.IssueClient(fmt.Sprintf("%s-client", Tag))
+		if err != nil {
+			return fmt.Errorf("failed to issue %s client certificate: %w", ContainerPrettyName, err)
+		}
+		// the entrypoint generates a CA of its own when neither a CA certificate
+		// nor a CA key is in place, and then reissues the server and client
+		// certificates from it; mounting the CA certificate without its key makes
+		// it pick up the mounted certificates as they are
+		req.Files = append(req.Files, testcontainers.ContainerFile{
+			Reader:            bytes.NewReader(ca.CertPEM),
+			ContainerFilePath: "/certs/ca/cert.pem",
+			FileMode:          0o644,
+		})
+		for _, bundle := range []struct {
+			dir     string
+			keyPair *pki.KeyPair
+		}{{"server", serverCert}, {"client", clientCert}} {
+			dir, keyPair := bundle.dir, bundle.keyPair
+			req.Files = append(req.Files,
+				testcontainers.ContainerFile{
+					Reader:            bytes.NewReader(ca.CertPEM),
+					ContainerFilePath: fmt.Sprintf("/certs/%s/ca.pem", dir),
+					FileMode:          0o644,
+				},
+				testcontainers.ContainerFile{
+					Reader:            bytes.NewReader(keyPair.CertPEM),
+					ContainerFilePath: fmt.Sprintf("/certs/%s/cert.pem", dir),
+					FileMode:          0o644,
+				},
+				testcontainers.ContainerFile{
+					Reader:            bytes.NewReader(keyPair.KeyPEM),
+					ContainerFilePath: fmt.Sprintf("/certs/%s/key.pem", dir),
+					FileMode:          0o600,
+				},
+			)
+		}
+		req.ExposedPorts = slices.DeleteFunc(req.ExposedPorts, func(port string) bool {
+			return port == Port
+		})
+		return WithTLS("/certs")(req)
+	}
+}
+
 // WithInsecureRegistries configures the Docker daemon to allow insecure registries
 func WithInsecureRegistries(registries ...string) testcontainers.CustomizeRequestOption {
 	return func(req *testcontainers.GenericContainerRequest) error {
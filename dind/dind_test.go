@@ -1,11 +1,14 @@
 package dind_test
 
 import (
+	"crypto/tls"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
 	container "github.com/narwhl/mockestra/dind"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
@@ -43,3 +46,75 @@ func TestDindModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestWithCAOption(t *testing.T) {
+	ca, err := pki.NewCA("dind-test CA")
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:         "dind-ca-option-test",
+			ExposedPorts: []string{container.Port, container.TLSPort},
+			Env:          make(map[string]string),
+		},
+	}
+	if err := container.WithCA(ca)(req); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+	if !slices.Equal(req.ExposedPorts, []string{container.TLSPort}) {
+		t.Errorf("expected only %s to be exposed, got %v", container.TLSPort, req.ExposedPorts)
+	}
+	var paths []string
+	for _, file := range req.Files {
+		paths = append(paths, file.ContainerFilePath)
+	}
+	if !slices.Contains(paths, "/certs/ca/cert.pem") || slices.Contains(paths, "/certs/ca/key.pem") {
+		t.Errorf("expected the CA certificate without its key, got %v", paths)
+	}
+}
+
+func TestWithCA(t *testing.T) {
+	var (
+		ca *pki.CA
+		c  testcontainers.Container
+	)
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"27",
+				fx.ResultTags(`name:"dind_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("dind-ca-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		pki.Module,
+		container.Module(),
+		fx.Populate(&ca, fx.Annotate(&c, fx.ParamTags(`name:"dind"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	endpoint, err := c.PortEndpoint(t.Context(), container.TLSPort, "")
+	if err != nil {
+		t.Fatalf("failed to get endpoint: %v", err)
+	}
+	client, err := ca.IssueClient("dind-test-client")
+	if err != nil {
+		t.Fatalf("failed to issue client certificate: %v", err)
+	}
+	config, err := ca.ClientTLSConfig(client)
+	if err != nil {
+		t.Fatalf("failed to build TLS config: %v", err)
+	}
+	// the handshake verifies that the served certificate chains to the stack CA
+	conn, err := tls.Dial("tcp", endpoint, config)
+	if err != nil {
+		t.Fatalf("failed to verify %s against the stack CA: %v", endpoint, err)
+	}
+	conn.Close()
+}
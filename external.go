@@ -0,0 +1,216 @@
+package mockestra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"go.uber.org/fx"
+)
+
+// ErrExternal is returned by ExternalContainer operations that need a container.
+var ErrExternal = errors.New("operation is not supported by an external service")
+
+// ExternalContainer stands in for the container of a service that is already
+// running, such as a CI service container or a shared development database.
+// It answers endpoint queries from ServicePorts and ignores lifecycle operations,
+// while operations that need a container fail with ErrExternal.
+type ExternalContainer struct {
+	// ServiceName identifies the service in logs, e.g. "postgres".
+	ServiceName string
+	// Address is the host the service is reachable at.
+	Address string
+	// ServicePorts maps the container ports of the module to the ports the service listens on.
+	ServicePorts map[nat.Port]string
+	// Labels are reported through Inspect, for hooks that read TLS settings from labels.
+	Labels map[string]string
+}
+
+var _ testcontainers.Container = (*ExternalContainer)(nil)
+
+// InternalEndpoint returns the host:port at which port of c is reachable from
+// other containers: the container IP and port for a container, or the
+// external address for an ExternalContainer.
+func InternalEndpoint(ctx context.Context, c testcontainers.Container, port nat.Port) (string, error) {
+	if external, ok := c.(*ExternalContainer); ok {
+		return external.PortEndpoint(ctx, port, "")
+	}
+	ip, err := c.ContainerIP(ctx)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ip, port.Port()), nil
+}
+
+// External provides the `name:"{tag}"` request and container of a module
+// for an ExternalContainer, so that dependents and client modules bind to the
+// running service instead of launching one. req carries the metadata
+// dependents read, such as credentials in Env.
+func External(tag string, req *testcontainers.GenericContainerRequest, c *ExternalContainer) fx.Option {
+	if req.Labels == nil {
+		req.Labels = make(map[string]string)
+	}
+	req.Labels[LabelModule] = tag
+	return fx.Options(
+		fx.Supply(
+			fx.Annotate(req, fx.ResultTags(fmt.Sprintf(`name:"%s"`, tag))),
+			fx.Annotate(req, fx.ResultTags(`group:"requests"`)),
+			fx.Annotate(
+				c,
+				fx.As(new(testcontainers.Container)),
+				fx.ResultTags(fmt.Sprintf(`name:"%s"`, tag)),
+			),
+			fx.Annotate(Requirement{Module: tag}, fx.ResultTags(`group:"requirements"`)),
+		),
+	)
+}
+
+func (c *ExternalContainer) GetContainerID() string {
+	return "external-" + c.ServiceName
+}
+
+func (c *ExternalContainer) Endpoint(ctx context.Context, proto string) (string, error) {
+	if len(c.ServicePorts) == 0 {
+		return "", fmt.Errorf("external %s has no ports", c.ServiceName)
+	}
+	ports := make([]nat.Port, 0, len(c.ServicePorts))
+	for port := range c.ServicePorts {
+		ports = append(ports, port)
+	}
+	slices.SortFunc(ports, func(a, b nat.Port) int { return a.Int() - b.Int() })
+	return c.PortEndpoint(ctx, ports[0], proto)
+}
+
+func (c *ExternalContainer) PortEndpoint(ctx context.Context, port nat.Port, proto string) (string, error) {
+	mapped, err := c.MappedPort(ctx, port)
+	if err != nil {
+		return "", err
+	}
+	endpoint := net.JoinHostPort(c.Address, mapped.Port())
+	if proto != "" {
+		endpoint = proto + "://" + endpoint
+	}
+	return endpoint, nil
+}
+
+func (c *ExternalContainer) Host(context.Context) (string, error) {
+	return c.Address, nil
+}
+
+func (c *ExternalContainer) Inspect(context.Context) (*container.InspectResponse, error) {
+	return &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:    c.GetContainerID(),
+			Name:  c.ServiceName,
+			State: &container.State{Status: container.StateRunning, Running: true},
+		},
+		Config: &container.Config{Labels: c.Labels},
+	}, nil
+}
+
+func (c *ExternalContainer) MappedPort(_ context.Context, port nat.Port) (nat.Port, error) {
+	external, ok := c.ServicePorts[port]
+	if !ok {
+		return "", fmt.Errorf("external %s does not serve port %s", c.ServiceName, port)
+	}
+	return nat.NewPort(port.Proto(), external)
+}
+
+func (c *ExternalContainer) Ports(ctx context.Context) (nat.PortMap, error) {
+	ports := make(nat.PortMap)
+	for port, external := range c.ServicePorts {
+		ports[port] = []nat.PortBinding{{HostIP: c.Address, HostPort: external}}
+	}
+	return ports, nil
+}
+
+func (c *ExternalContainer) SessionID() string {
+	return ""
+}
+
+func (c *ExternalContainer) IsRunning() bool {
+	return true
+}
+
+func (c *ExternalContainer) Start(context.Context) error {
+	return nil
+}
+
+func (c *ExternalContainer) Stop(context.Context, *time.Duration) error {
+	return nil
+}
+
+// Terminate leaves the external service running.
+func (c *ExternalContainer) Terminate(context.Context, ...testcontainers.TerminateOption) error {
+	return nil
+}
+
+func (c *ExternalContainer) Logs(context.Context) (io.ReadCloser, error) {
+	return nil, ErrExternal
+}
+
+func (c *ExternalContainer) FollowOutput(testcontainers.LogConsumer) {}
+
+func (c *ExternalContainer) StartLogProducer(context.Context, ...testcontainers.LogProductionOption) error {
+	return ErrExternal
+}
+
+func (c *ExternalContainer) StopLogProducer() error {
+	return nil
+}
+
+func (c *ExternalContainer) Name(context.Context) (string, error) {
+	return c.ServiceName, nil
+}
+
+func (c *ExternalContainer) State(context.Context) (*container.State, error) {
+	return &container.State{Status: container.StateRunning, Running: true}, nil
+}
+
+func (c *ExternalContainer) Networks(context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (c *ExternalContainer) NetworkAliases(context.Context) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (c *ExternalContainer) Exec(context.Context, []string, ...tcexec.ProcessOption) (int, io.Reader, error) {
+	return 0, nil, ErrExternal
+}
+
+func (c *ExternalContainer) ContainerIP(context.Context) (string, error) {
+	return c.Address, nil
+}
+
+func (c *ExternalContainer) ContainerIPs(context.Context) ([]string, error) {
+	return []string{c.Address}, nil
+}
+
+func (c *ExternalContainer) CopyToContainer(context.Context, []byte, string, int64) error {
+	return ErrExternal
+}
+
+func (c *ExternalContainer) CopyDirToContainer(context.Context, string, string, int64) error {
+	return ErrExternal
+}
+
+func (c *ExternalContainer) CopyFileToContainer(context.Context, string, string, int64) error {
+	return ErrExternal
+}
+
+func (c *ExternalContainer) CopyFileFromContainer(context.Context, string) (io.ReadCloser, error) {
+	return nil, ErrExternal
+}
+
+func (c *ExternalContainer) GetLogProductionErrorChannel() <-chan error {
+	return nil
+}
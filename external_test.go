@@ -0,0 +1,78 @@
+package mockestra_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestExternalContainer(t *testing.T) {
+	c := &mockestra.ExternalContainer{
+		ServiceName:  "postgres",
+		Address:      "db.internal",
+		ServicePorts: map[nat.Port]string{"5432/tcp": "6543", "8080/tcp": "8081"},
+	}
+
+	endpoint, err := c.Endpoint(t.Context(), "")
+	if err != nil || endpoint != "db.internal:6543" {
+		t.Errorf("expected lowest port endpoint db.internal:6543, got %s, %v", endpoint, err)
+	}
+	endpoint, err = c.PortEndpoint(t.Context(), "8080/tcp", "http")
+	if err != nil || endpoint != "http://db.internal:8081" {
+		t.Errorf("expected endpoint http://db.internal:8081, got %s, %v", endpoint, err)
+	}
+	if _, err := c.MappedPort(t.Context(), "9999/tcp"); err == nil {
+		t.Error("expected unknown port to fail")
+	}
+	endpoint, err = mockestra.InternalEndpoint(t.Context(), c, "5432/tcp")
+	if err != nil || endpoint != "db.internal:6543" {
+		t.Errorf("expected internal endpoint db.internal:6543, got %s, %v", endpoint, err)
+	}
+	if _, _, err := c.Exec(t.Context(), []string{"true"}); !errors.Is(err, mockestra.ErrExternal) {
+		t.Errorf("expected Exec to fail with ErrExternal, got %v", err)
+	}
+	if err := c.Terminate(t.Context()); err != nil {
+		t.Errorf("expected Terminate to leave the service alone, got %v", err)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Env: map[string]string{"POSTGRES_USER": "app"},
+		},
+	}
+	var p struct {
+		fx.In
+		Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+		Container testcontainers.Container                `name:"postgres"`
+	}
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		mockestra.External("postgres", req, &mockestra.ExternalContainer{
+			ServiceName:  "postgres",
+			Address:      "db.internal",
+			ServicePorts: map[nat.Port]string{"5432/tcp": "5432"},
+		}),
+		testModule("hydra", "postgres"),
+		fx.Supply(fx.Annotate("external-test", fx.ResultTags(`name:"prefix"`))),
+		fx.Options(mockestra.Versions(map[string]string{"hydra": "1"})...),
+		mockestra.ValidateModule(),
+		fx.Populate(&p),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	if p.Request.Env["POSTGRES_USER"] != "app" {
+		t.Errorf("expected request metadata to be provided, got %v", p.Request.Env)
+	}
+	if endpoint, err := p.Container.Endpoint(t.Context(), ""); err != nil || endpoint != "db.internal:5432" {
+		t.Errorf("unexpected external endpoint %s, %v", endpoint, err)
+	}
+}
@@ -0,0 +1,71 @@
+package mockestra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// InProcess switches every module of the stack that has an in-process fake
+// backend, such as nats, redis, valkey, minio, rustfs and versitygw, to it.
+// The fakes serve on loopback behind the same `name:"{tag}"` values as their
+// containers, so client modules keep working, while modules without a fake
+// still launch their container. Fakes are not reachable from containers.
+func InProcess() fx.Option {
+	return fx.Supply(
+		fx.Annotate(
+			true,
+			fx.ResultTags(`name:"in_process"`),
+		),
+	)
+}
+
+// FakeContainer returns an ExternalContainer for the in-process fake of
+// module tag listening at addr, answering every one of ports with it.
+func FakeContainer(tag, addr string, ports ...nat.Port) (*ExternalContainer, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid in-process %s address %s: %w", tag, addr, err)
+	}
+	c := &ExternalContainer{
+		ServiceName:  tag,
+		Address:      host,
+		ServicePorts: make(map[nat.Port]string),
+		Labels:       map[string]string{LabelModule: tag},
+	}
+	for _, p := range ports {
+		c.ServicePorts[p] = port
+	}
+	return c, nil
+}
+
+// RunPostReadyHooks runs the PostReadies hooks of req against c, so options
+// such as WithBucket and WithStream apply to in-process fakes as well.
+func RunPostReadyHooks(ctx context.Context, req *testcontainers.GenericContainerRequest, c testcontainers.Container) error {
+	for _, hooks := range req.LifecycleHooks {
+		for _, hook := range hooks.PostReadies {
+			if err := hook(ctx, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ServeFake serves handler on a random loopback port for an in-process fake.
+// It returns the address served and a func shutting the server down.
+func ServeFake(handler http.Handler) (string, func(context.Context) error, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(LoopbackAddress, "0"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen on loopback: %w", err)
+	}
+	server := &http.Server{Handler: handler}
+	// Serve only returns once the server is shut down
+	go server.Serve(listener)
+	return listener.Addr().String(), server.Shutdown, nil
+}
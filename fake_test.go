@@ -0,0 +1,58 @@
+package mockestra_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestServeFake(t *testing.T) {
+	addr, shutdown, err := mockestra.ServeFake(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "fake")
+	}))
+	if err != nil {
+		t.Fatalf("failed to serve fake: %v", err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	c, err := mockestra.FakeContainer("web", addr, "80/tcp", "443/tcp")
+	if err != nil {
+		t.Fatalf("failed to create fake container: %v", err)
+	}
+	var endpoints []string
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			LifecycleHooks: []testcontainers.ContainerLifecycleHooks{{
+				PostReadies: []testcontainers.ContainerHook{
+					func(ctx context.Context, c testcontainers.Container) error {
+						endpoint, err := c.PortEndpoint(ctx, "80/tcp", "http")
+						endpoints = append(endpoints, endpoint)
+						return err
+					},
+				},
+			}},
+		},
+	}
+	if err := mockestra.RunPostReadyHooks(t.Context(), req, c); err != nil {
+		t.Fatalf("failed to run hooks: %v", err)
+	}
+	if len(endpoints) != 1 || endpoints[0] != "http://"+addr {
+		t.Fatalf("expected hook to see http://%s, got %v", addr, endpoints)
+	}
+
+	resp, err := http.Get(endpoints[0])
+	if err != nil {
+		t.Fatalf("failed to reach fake: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "fake" {
+		t.Errorf("expected fake response, got %q", body)
+	}
+	if tlsEndpoint, _ := c.PortEndpoint(t.Context(), "443/tcp", ""); tlsEndpoint != addr {
+		t.Errorf("expected every port to map to %s, got %s", addr, tlsEndpoint)
+	}
+}
@@ -0,0 +1,246 @@
+package mockestra
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/narwhl/mockestra/proxy"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// containerType and proxyType are how the fx graph names the containers and
+// access proxies modules provide.
+var (
+	containerType = reflect.TypeFor[testcontainers.Container]().String()
+	proxyType     = reflect.TypeFor[*proxy.TCPProxy]().String()
+)
+
+// GraphModule is a module of a StackGraph.
+type GraphModule struct {
+	Tag       string
+	Image     string
+	Ports     []string
+	Proxies   []string
+	DependsOn []string
+}
+
+// label describes the module on lines joined by sep.
+func (m GraphModule) label(sep string) string {
+	lines := []string{m.Tag}
+	if m.Image != "" {
+		lines = append(lines, m.Image)
+	}
+	if len(m.Ports) > 0 {
+		lines = append(lines, strings.Join(m.Ports, ", "))
+	}
+	return strings.Join(lines, sep)
+}
+
+// StackGraph is the topology of a stack as built from its fx providers.
+type StackGraph struct {
+	Modules []GraphModule
+}
+
+// graphConstructor is a constructor of the fx graph with the named values
+// it consumes and provides.
+type graphConstructor struct {
+	params  []graphValue
+	results []graphValue
+}
+
+// graphValue is a named value of the fx graph, such as
+// testcontainers.Container[name=postgres].
+type graphValue struct {
+	Type string
+	Name string
+}
+
+// parseDotGraph returns the constructors of graph, as rendered by dig, which
+// lists the results of each constructor in its cluster and its parameters as
+// edges from the cluster, e.g.
+//
+//	subgraph cluster_47 {
+//		constructor_47 [shape=plaintext label="NewProxy"];
+//		"*proxy.TCPProxy[name=kratos]" [label=<...>];
+//	}
+//	constructor_47 -> "testcontainers.Container[name=kratos]" [ltail=cluster_47];
+//
+// Unnamed values and value groups are left out. It only serves to find the
+// access proxies of modules, which declare no requirement of their own.
+func parseDotGraph(graph fx.DotGraph) map[string]*graphConstructor {
+	constructors := make(map[string]*graphConstructor)
+	constructor := func(id string) *graphConstructor {
+		if constructors[id] == nil {
+			constructors[id] = &graphConstructor{}
+		}
+		return constructors[id]
+	}
+	var cluster string
+	for line := range strings.Lines(string(graph)) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "subgraph cluster_"):
+			cluster = "constructor_" + strings.TrimSuffix(strings.TrimPrefix(line, "subgraph cluster_"), " {")
+		case line == "}":
+			cluster = ""
+		case strings.HasPrefix(line, "constructor_"):
+			id, rest, ok := strings.Cut(line, " -> ")
+			if !ok {
+				continue
+			}
+			if value, ok := parseGraphValue(rest); ok {
+				constructor(id).params = append(constructor(id).params, value)
+			}
+		case cluster != "" && strings.HasPrefix(line, `"`):
+			if value, ok := parseGraphValue(line); ok {
+				constructor(cluster).results = append(constructor(cluster).results, value)
+			}
+		}
+	}
+	return constructors
+}
+
+// parseGraphValue parses the quoted `type[name=name]` node at the start of s.
+func parseGraphValue(s string) (graphValue, bool) {
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return graphValue{}, false
+	}
+	node, _ := strconv.Unquote(quoted)
+	typ, name, ok := strings.Cut(node, "[name=")
+	if !ok {
+		return graphValue{}, false
+	}
+	return graphValue{Type: typ, Name: strings.TrimSuffix(name, "]")}, true
+}
+
+// proxies returns the access proxies provided from the container of module.
+func proxies(constructors map[string]*graphConstructor, module string) []string {
+	var names []string
+	for _, c := range constructors {
+		if !slices.Contains(c.params, graphValue{Type: containerType, Name: module}) {
+			continue
+		}
+		for _, result := range c.results {
+			if result.Type == proxyType {
+				names = append(names, result.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+type GraphParams struct {
+	fx.In
+	Dot          fx.DotGraph
+	Requirements []Requirement                             `group:"requirements"`
+	Dependencies []Dependency                              `group:"dependencies"`
+	Requests     []*testcontainers.GenericContainerRequest `group:"requests"`
+}
+
+// Graph builds the stack of opts, without starting any container, and returns
+// its modules with their image, exposed ports, access proxies and the modules
+// they depend on. opts are the module, version and prefix options of the
+// stack; an fx.Invoke consuming containers would create them.
+//
+// Dependencies are the ones modules declare with DependsOn. A requirement
+// standing in for a module, such as the postgres one of UseSQLDatabase, is
+// resolved to the modules it declares to depend on.
+func Graph(opts ...fx.Option) (*StackGraph, error) {
+	var graph StackGraph
+	app := fx.New(
+		fx.Options(opts...),
+		fx.NopLogger,
+		fx.Invoke(func(p GraphParams) {
+			requested := make(map[string]*testcontainers.GenericContainerRequest)
+			for _, req := range p.Requests {
+				requested[req.Labels[LabelModule]] = req
+			}
+			dependencies := make(map[string][]string)
+			for _, d := range p.Dependencies {
+				dependencies[d.Module] = append(dependencies[d.Module], d.On...)
+			}
+			var resolve func(tag string, seen []string) []string
+			resolve = func(tag string, seen []string) []string {
+				if _, ok := requested[tag]; ok || len(dependencies[tag]) == 0 || slices.Contains(seen, tag) {
+					return []string{tag}
+				}
+				var modules []string
+				for _, on := range dependencies[tag] {
+					modules = append(modules, resolve(on, append(seen, tag))...)
+				}
+				return modules
+			}
+
+			constructors := parseDotGraph(p.Dot)
+			for _, r := range p.Requirements {
+				req, ok := requested[r.Module]
+				if !ok && len(dependencies[r.Module]) > 0 {
+					// stands in for the modules it depends on
+					continue
+				}
+				module := GraphModule{Tag: r.Module, Proxies: proxies(constructors, r.Module)}
+				if ok {
+					module.Image = req.Image
+					module.Ports = slices.Sorted(slices.Values(req.ExposedPorts))
+				}
+				for _, on := range dependencies[r.Module] {
+					module.DependsOn = append(module.DependsOn, resolve(on, []string{r.Module})...)
+				}
+				slices.Sort(module.DependsOn)
+				module.DependsOn = slices.Compact(module.DependsOn)
+				graph.Modules = append(graph.Modules, module)
+			}
+		}),
+	)
+	if err := app.Err(); err != nil {
+		return nil, fmt.Errorf("failed to build stack graph: %w", err)
+	}
+	slices.SortFunc(graph.Modules, func(a, b GraphModule) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return &graph, nil
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *StackGraph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, m := range g.Modules {
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", m.Tag, m.label("<br/>"))
+		for _, proxy := range m.Proxies {
+			fmt.Fprintf(&b, "    proxy_%s([\"proxy %s\"]) --> %s\n", proxy, proxy, m.Tag)
+		}
+	}
+	for _, m := range g.Modules {
+		for _, dependency := range m.DependsOn {
+			fmt.Fprintf(&b, "    %s --> %s\n", m.Tag, dependency)
+		}
+	}
+	return b.String()
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (g *StackGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph mockestra {\n    rankdir=LR;\n")
+	for _, m := range g.Modules {
+		fmt.Fprintf(&b, "    %q [shape=box, label=%q];\n", m.Tag, m.label("\n"))
+		for _, proxy := range m.Proxies {
+			fmt.Fprintf(&b, "    %q [shape=ellipse, label=%q];\n", "proxy:"+proxy, "proxy "+proxy)
+			fmt.Fprintf(&b, "    %q -> %q;\n", "proxy:"+proxy, m.Tag)
+		}
+	}
+	for _, m := range g.Modules {
+		for _, dependency := range m.DependsOn {
+			fmt.Fprintf(&b, "    %q -> %q;\n", m.Tag, dependency)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
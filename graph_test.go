@@ -0,0 +1,91 @@
+package mockestra_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/hydra"
+	"github.com/narwhl/mockestra/kratos"
+	"github.com/narwhl/mockestra/mailslurper"
+	"github.com/narwhl/mockestra/postgres"
+	"github.com/narwhl/mockestra/timescaledb"
+	"go.uber.org/fx"
+)
+
+func TestGraph(t *testing.T) {
+	graph, err := mockestra.Graph(
+		fx.Supply(fx.Annotate("graph-test", fx.ResultTags(`name:"prefix"`))),
+		mockestra.SecretsModule(),
+		fx.Options(mockestra.Versions(mockestra.DefaultVersions())...),
+		postgres.Module(),
+		hydra.Module(),
+		mailslurper.Module(),
+		kratos.Module(),
+	)
+	if err != nil {
+		t.Fatalf("Graph failed: %v", err)
+	}
+
+	var tags []string
+	for _, m := range graph.Modules {
+		tags = append(tags, m.Tag)
+	}
+	if !slices.Equal(tags, []string{"hydra", "kratos", "mailslurper", "postgres"}) {
+		t.Fatalf("unexpected modules %v", tags)
+	}
+	kratosModule := graph.Modules[1]
+	if !slices.Equal(kratosModule.DependsOn, []string{"hydra", "mailslurper", "postgres"}) {
+		t.Errorf("unexpected kratos dependencies %v", kratosModule.DependsOn)
+	}
+	if !slices.Equal(kratosModule.Proxies, []string{"kratos", "kratosadmin"}) {
+		t.Errorf("unexpected kratos proxies %v", kratosModule.Proxies)
+	}
+	if !slices.Contains(kratosModule.Ports, kratos.Port) || kratosModule.Image != "oryd/kratos:"+mockestra.DefaultVersions()["kratos"] {
+		t.Errorf("unexpected kratos container %s %v", kratosModule.Image, kratosModule.Ports)
+	}
+
+	mermaid := graph.Mermaid()
+	for _, line := range []string{"flowchart LR", "kratos --> hydra", "proxy_hydraadmin([\"proxy hydraadmin\"]) --> hydra"} {
+		if !strings.Contains(mermaid, line) {
+			t.Errorf("expected Mermaid graph to contain %q, got:\n%s", line, mermaid)
+		}
+	}
+	dot := graph.DOT()
+	for _, line := range []string{"digraph mockestra {", `"kratos" -> "mailslurper";`, `"proxy:kratosadmin" -> "kratos";`} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected DOT graph to contain %q, got:\n%s", line, dot)
+		}
+	}
+}
+
+func TestGraphUseSQLDatabase(t *testing.T) {
+	graph, err := mockestra.Graph(
+		fx.Supply(fx.Annotate("graph-test", fx.ResultTags(`name:"prefix"`))),
+		mockestra.SecretsModule(),
+		fx.Options(mockestra.Versions(mockestra.DefaultVersions())...),
+		timescaledb.Module(),
+		hydra.Module(),
+		mailslurper.Module(),
+		kratos.Module(),
+		mockestra.UseSQLDatabase(timescaledb.Tag),
+	)
+	if err != nil {
+		t.Fatalf("Graph failed: %v", err)
+	}
+
+	var tags []string
+	for _, m := range graph.Modules {
+		tags = append(tags, m.Tag)
+	}
+	if !slices.Equal(tags, []string{"hydra", "kratos", "mailslurper", "timescaledb"}) {
+		t.Fatalf("unexpected modules %v", tags)
+	}
+	if kratosModule := graph.Modules[1]; !slices.Equal(kratosModule.DependsOn, []string{"hydra", "mailslurper", "timescaledb"}) {
+		t.Errorf("unexpected kratos dependencies %v", kratosModule.DependsOn)
+	}
+	if hydraModule := graph.Modules[0]; !slices.Equal(hydraModule.DependsOn, []string{"timescaledb"}) {
+		t.Errorf("unexpected hydra dependencies %v", hydraModule.DependsOn)
+	}
+}
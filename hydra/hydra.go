@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/narwhl/mockestra"
@@ -66,18 +67,19 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"hydra_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"hydra"`
+	Secrets *mockestra.SecretSource
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
-	hydraCookieSecret, err := mockestra.RandomPassword(32)
+	hydraCookieSecret, err := p.Secrets.Secret(mockestra.SecretName(Tag, "cookie"), 32)
 	if err != nil {
 		return nil, err
 	}
-	hydraSystemSecret, err := mockestra.RandomPassword(32)
+	hydraSystemSecret, err := p.Secrets.Secret(mockestra.SecretName(Tag, "system"), 32)
 	if err != nil {
 		return nil, err
 	}
-	oidcPairwiseSalt, err := mockestra.RandomPassword(32)
+	oidcPairwiseSalt, err := p.Secrets.Secret(mockestra.SecretName(Tag, "pairwise_salt"), 32)
 	if err != nil {
 		return nil, err
 	}
@@ -112,11 +114,11 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 
 type ContainerParams struct {
 	fx.In
-	Lifecycle                fx.Lifecycle
-	Prefix                   string                                  `name:"prefix"`
-	PostgresContainerRequest *testcontainers.GenericContainerRequest `name:"postgres"`
-	PostgresContainer        testcontainers.Container                `name:"postgres"`
-	Request                  *testcontainers.GenericContainerRequest `name:"hydra"`
+	Lifecycle fx.Lifecycle
+	Prefix    string                                  `name:"prefix"`
+	Database  mockestra.SQLDatabase                   `name:"postgres"`
+	Request   *testcontainers.GenericContainerRequest `name:"hydra"`
+	Secrets   *mockestra.SecretSource
 }
 
 type Result struct {
@@ -126,17 +128,19 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
-	postgresIP, err := p.PostgresContainer.ContainerIP(context.Background())
+	postgresHost, postgresPort, err := p.Database.Address(context.Background())
 	if err != nil {
-		return Result{}, fmt.Errorf("failed to get %s container IP: %w", postgres.ContainerPrettyName, err)
+		return Result{}, fmt.Errorf("failed to get database address: %w", err)
+	}
+	postgresUser, postgresPassword, err := mockestra.ProvisionDatabase(context.Background(), p.Database, p.Secrets, Tag, DatabaseName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to provision %s database: %w", ContainerPrettyName, err)
 	}
-	_, postgresPort := nat.SplitProtoPort(postgres.Port)
 
-	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		p.PostgresContainerRequest.Env["POSTGRES_USER"], // TODO: use database specific user instead of admin user
-		p.PostgresContainerRequest.Env["POSTGRES_PASSWORD"],
-		postgresIP,
-		postgresPort,
+	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+		postgresUser,
+		postgresPassword,
+		net.JoinHostPort(postgresHost, postgresPort),
 		DatabaseName,
 	)).Customize(p.Request); err != nil {
 		return Result{}, err
@@ -213,4 +217,5 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"hydraadmin"`),
 		),
 	),
+	mockestra.DependsOn(Tag, postgres.Tag),
 )
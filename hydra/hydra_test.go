@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
 	"github.com/narwhl/mockestra/hydra"
 	"github.com/narwhl/mockestra/postgres"
 	"github.com/narwhl/mockestra/proxy"
@@ -22,6 +23,7 @@ func TestHydraModule_SmokeTest(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -40,7 +42,6 @@ func TestHydraModule_SmokeTest(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(),
 		fx.Invoke(func(params struct {
@@ -97,6 +98,7 @@ func TestHydraModule_WithPostgres(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -115,7 +117,6 @@ func TestHydraModule_WithPostgres(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithPostgres(dsn),
@@ -144,6 +145,7 @@ func TestHydraModule_WithURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -162,7 +164,6 @@ func TestHydraModule_WithURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithURL(issuerURL),
@@ -191,6 +192,7 @@ func TestHydraModule_WithSelfServiceUIURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -209,7 +211,6 @@ func TestHydraModule_WithSelfServiceUIURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithSelfServiceUIURL(uiURL),
@@ -246,6 +247,7 @@ func TestHydraModule_WithKratosPublicURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -264,7 +266,6 @@ func TestHydraModule_WithKratosPublicURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithKratosPublicURL(kratosPublicURL),
@@ -293,6 +294,7 @@ func TestHydraModule_WithKratosURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -311,7 +313,6 @@ func TestHydraModule_WithKratosURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithKratosURL(kratosURL),
@@ -340,6 +341,7 @@ func TestHydraModule_WithPostReadyHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -358,7 +360,6 @@ func TestHydraModule_WithPostReadyHook(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithPostReadyHook(func(endpoints map[string]string) error {
@@ -391,6 +392,7 @@ func TestHydraModule_ProxyConfiguration(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -409,7 +411,6 @@ func TestHydraModule_ProxyConfiguration(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(),
 		fx.Invoke(func(params struct {
@@ -469,6 +470,7 @@ func TestHydraModule_MultipleDecorators(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -487,7 +489,6 @@ func TestHydraModule_MultipleDecorators(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithPostgres(dsn),
@@ -533,6 +534,7 @@ func TestHydraModule_WithGenerateClientCredentialsHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -551,7 +553,6 @@ func TestHydraModule_WithGenerateClientCredentialsHook(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithGenerateClientCredentialsHook(
@@ -676,6 +677,7 @@ func TestHydraModule_FullIntegration(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -694,7 +696,6 @@ func TestHydraModule_FullIntegration(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
 		),
 		hydra.Module(
 			hydra.WithURL("https://auth.example.com"),
@@ -3,19 +3,13 @@ package kanidm
 import (
 	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
 	"fmt"
 	"log/slog"
-	"math/big"
 	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"go.uber.org/fx"
@@ -113,72 +107,34 @@ func WithDBArcSize(size int) testcontainers.CustomizeRequestOption {
 	}
 }
 
-// generateSelfSignedCert generates a self-signed TLS certificate for testing.
-func generateSelfSignedCert(domain string) (certPEM, keyPEM []byte, err error) {
-	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
-	}
-
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
-	}
-
-	template := x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization: []string{"Mockestra Test"},
-			CommonName:   domain,
-		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		BasicConstraintsValid: true,
-		DNSNames:              []string{domain, "localhost"},
-	}
-
-	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
-	}
-
-	certBuf := new(bytes.Buffer)
-	if err := pem.Encode(certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: certDER}); err != nil {
-		return nil, nil, fmt.Errorf("failed to encode certificate: %w", err)
-	}
-
-	keyDER, err := x509.MarshalECPrivateKey(priv)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal private key: %w", err)
-	}
-
-	keyBuf := new(bytes.Buffer)
-	if err := pem.Encode(keyBuf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}); err != nil {
-		return nil, nil, fmt.Errorf("failed to encode private key: %w", err)
-	}
-
-	return certBuf.Bytes(), keyBuf.Bytes(), nil
-}
-
 type RequestParams struct {
 	fx.In
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"kanidm_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"kanidm"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
-	// Generate self-signed TLS certificates for the container
-	certPEM, keyPEM, err := generateSelfSignedCert(DefaultDomain)
+	// Issue TLS certificates from the stack CA, falling back to a throwaway
+	// CA when the pki module is not part of the stack
+	ca := p.CA
+	if ca == nil {
+		var err error
+		ca, err = pki.NewCA(fmt.Sprintf("mock-%s-%s CA", p.Prefix, Tag))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate TLS certificates: %w", err)
+		}
+	}
+	name := fmt.Sprintf("mock-%s-%s", p.Prefix, Tag)
+	serverCert, err := ca.IssueServer(DefaultDomain, name, Tag)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate TLS certificates: %w", err)
 	}
 
 	r := testcontainers.GenericContainerRequest{
 		ContainerRequest: testcontainers.ContainerRequest{
-			Name:  fmt.Sprintf("mock-%s-%s", p.Prefix, Tag),
+			Name:  name,
 			Image: fmt.Sprintf("%s:%s", Image, p.Version),
 			ExposedPorts: []string{
 				Port,
@@ -194,12 +150,12 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 			Files: []testcontainers.ContainerFile{
 				{
 					ContainerFilePath: "/data/chain.pem",
-					Reader:            bytes.NewReader(certPEM),
+					Reader:            bytes.NewReader(bytes.Join([][]byte{serverCert.CertPEM, ca.CertPEM}, nil)),
 					FileMode:          0o644,
 				},
 				{
 					ContainerFilePath: "/data/key.pem",
-					Reader:            bytes.NewReader(keyPEM),
+					Reader:            bytes.NewReader(serverCert.KeyPEM),
 					FileMode:          0o600,
 				},
 			},
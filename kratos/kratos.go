@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 
 	"github.com/docker/go-connections/nat"
@@ -236,14 +237,15 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"kratos_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"kratos"`
+	Secrets *mockestra.SecretSource
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
-	kratosCookieSecret, err := mockestra.RandomPassword(32)
+	kratosCookieSecret, err := p.Secrets.Secret(mockestra.SecretName(Tag, "cookie"), 32)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate kratos cookie secret: %w", err)
 	}
-	kratosSystemSecret, err := mockestra.RandomPassword(16)
+	kratosSystemSecret, err := p.Secrets.Secret(mockestra.SecretName(Tag, "cipher"), 16)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate kratos system secret: %w", err)
 	}
@@ -315,13 +317,13 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 
 type ContainerParams struct {
 	fx.In
-	Lifecycle                fx.Lifecycle
-	Prefix                   string                                  `name:"prefix"`
-	HydraContainer           testcontainers.Container                `name:"hydra"`
-	MailslurperContainer     testcontainers.Container                `name:"mailslurper"`
-	PostgresContainerRequest *testcontainers.GenericContainerRequest `name:"postgres"`
-	PostgresContainer        testcontainers.Container                `name:"postgres"`
-	Request                  *testcontainers.GenericContainerRequest `name:"kratos"`
+	Lifecycle            fx.Lifecycle
+	Prefix               string                                  `name:"prefix"`
+	HydraContainer       testcontainers.Container                `name:"hydra"`
+	MailslurperContainer testcontainers.Container                `name:"mailslurper"`
+	Database             mockestra.SQLDatabase                   `name:"postgres"`
+	Request              *testcontainers.GenericContainerRequest `name:"kratos"`
+	Secrets              *mockestra.SecretSource
 }
 
 type Result struct {
@@ -343,21 +345,23 @@ func Actualize(p ContainerParams) (Result, error) {
 	}
 	_, mailslurperPort := nat.SplitProtoPort(mailslurper.SMTPPort)
 
-	postgresIP, err := p.PostgresContainer.ContainerIP(context.Background())
+	postgresHost, postgresPort, err := p.Database.Address(context.Background())
 	if err != nil {
-		return Result{}, fmt.Errorf("failed to get %s container IP: %w", postgres.ContainerPrettyName, err)
+		return Result{}, fmt.Errorf("failed to get database address: %w", err)
+	}
+	postgresUser, postgresPassword, err := mockestra.ProvisionDatabase(context.Background(), p.Database, p.Secrets, Tag, DatabaseName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to provision %s database: %w", ContainerPrettyName, err)
 	}
-	_, postgresPort := nat.SplitProtoPort(postgres.Port)
 
 	if err := WithHydraAdminURL(fmt.Sprintf("http://%s:%s", hydraIP, hydraAdminPort)).Customize(p.Request); err != nil {
 		return Result{}, fmt.Errorf("failed to set hydra url: %w", err)
 	}
 
-	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		p.PostgresContainerRequest.Env["POSTGRES_USER"], // TODO: use database specific user instead of admin user
-		p.PostgresContainerRequest.Env["POSTGRES_PASSWORD"],
-		postgresIP,
-		postgresPort,
+	if err := WithPostgres(fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+		postgresUser,
+		postgresPassword,
+		net.JoinHostPort(postgresHost, postgresPort),
 		DatabaseName,
 	)).Customize(p.Request); err != nil {
 		return Result{}, fmt.Errorf("failed to set postgres url: %w", err)
@@ -441,4 +445,5 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"kratosadmin"`),
 		),
 	),
+	mockestra.DependsOn(Tag, hydra.Tag, mailslurper.Tag, postgres.Tag),
 )
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
 	"github.com/narwhl/mockestra/hydra"
 	"github.com/narwhl/mockestra/kratos"
 	"github.com/narwhl/mockestra/mailslurper"
@@ -25,6 +26,7 @@ func TestKratosModule_SmokeTest(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -51,8 +53,6 @@ func TestKratosModule_SmokeTest(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -111,6 +111,7 @@ func TestKratosModule_WithPostgres(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -137,8 +138,6 @@ func TestKratosModule_WithPostgres(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -169,6 +168,7 @@ func TestKratosModule_WithURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -195,8 +195,6 @@ func TestKratosModule_WithURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -227,6 +225,7 @@ func TestKratosModule_WithAdminURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -253,8 +252,6 @@ func TestKratosModule_WithAdminURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -285,6 +282,7 @@ func TestKratosModule_WithRootDomain(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -311,8 +309,6 @@ func TestKratosModule_WithRootDomain(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -359,6 +355,7 @@ func TestKratosModule_WithRegistrationHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -385,8 +382,6 @@ func TestKratosModule_WithRegistrationHook(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -428,6 +423,7 @@ func TestKratosModule_WithSelfServiceUIURL(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -454,8 +450,6 @@ func TestKratosModule_WithSelfServiceUIURL(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -542,6 +536,7 @@ func TestKratosModule_WithOIDCConfig(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -568,8 +563,6 @@ func TestKratosModule_WithOIDCConfig(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -636,6 +629,7 @@ func TestKratosModule_WithPostReadyHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -662,8 +656,6 @@ func TestKratosModule_WithPostReadyHook(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -698,6 +690,7 @@ func TestKratosModule_ProxyConfiguration(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -724,8 +717,6 @@ func TestKratosModule_ProxyConfiguration(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -812,6 +803,7 @@ func TestKratosModule_WithIdentitySchema(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -838,8 +830,6 @@ func TestKratosModule_WithIdentitySchema(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -893,6 +883,7 @@ func TestKratosModule_WithSmtpURI(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -919,8 +910,6 @@ func TestKratosModule_WithSmtpURI(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -958,6 +947,7 @@ func TestKratosModule_WithSettingsHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -984,8 +974,6 @@ func TestKratosModule_WithSettingsHook(t *testing.T) {
 			postgres.WithUsername("dbuser"),
 			postgres.WithPassword("dbpass"),
 			postgres.WithDatabase("testdb"),
-			postgres.WithExtraDatabase(hydra.DatabaseName, "hydrauser", "hydrapass"),
-			postgres.WithExtraDatabase(kratos.DatabaseName, "kratosuser", "kratospass"),
 		),
 		mailslurper.Module(),
 		hydra.Module(),
@@ -0,0 +1,147 @@
+package mockestra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/opencontainers/go-digest"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// LockfileName is the default name of the lockfile written by `mockestra lock`.
+const LockfileName = "mockestra.lock"
+
+// Lockfile pins the images of a stack by digest, mapping module tag to
+// image reference to digest, e.g. {"postgres": {"postgres:17-alpine": "sha256:…"}}.
+type Lockfile map[string]map[string]string
+
+// LoadLockfile reads the lockfile at path.
+func LoadLockfile(path string) (Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile %s: %w", path, err)
+	}
+	lockfile := make(Lockfile)
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	return lockfile, nil
+}
+
+// Save writes the lockfile to path, with modules and images sorted so that
+// regenerating an unchanged lockfile yields no diff.
+func (l Lockfile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile %s: %w", path, err)
+	}
+	return nil
+}
+
+// Set pins image of module tag to dgst.
+func (l Lockfile) Set(tag, image, dgst string) {
+	if l[tag] == nil {
+		l[tag] = make(map[string]string)
+	}
+	l[tag][image] = dgst
+}
+
+// Pin returns image of module tag rewritten to its pinned `name@sha256:…` form.
+// It fails when the lockfile has no entry for the module or the image,
+// or when the pinned digest is malformed.
+func (l Lockfile) Pin(tag, image string) (string, error) {
+	images, ok := l[tag]
+	if !ok || len(images) == 0 {
+		return "", fmt.Errorf("lockfile has no entry for module %s, run `mockestra lock %s=%s` to pin it", tag, tag, image)
+	}
+	dgst, ok := images[image]
+	if !ok {
+		return "", fmt.Errorf("lockfile pins module %s to %s but the stack requests %s, run `mockestra lock %s=%s` to update it", tag, strings.Join(slices.Sorted(maps.Keys(images)), ", "), image, tag, image)
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %s of module %s: %w", image, tag, err)
+	}
+	parsed, err := digest.Parse(dgst)
+	if err != nil {
+		return "", fmt.Errorf("lockfile pins module %s image %s to invalid digest %q: %w", tag, image, dgst, err)
+	}
+	canonical, err := reference.WithDigest(reference.TrimNamed(named), parsed)
+	if err != nil {
+		return "", fmt.Errorf("failed to pin image %s of module %s: %w", image, tag, err)
+	}
+	return reference.FamiliarString(canonical), nil
+}
+
+// DistributionClient is the subset of the Docker client Lock needs,
+// satisfied by *testcontainers.DockerClient.
+type DistributionClient interface {
+	DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (registry.DistributionInspect, error)
+}
+
+// Lock resolves the digest of every image, given as module tag to image
+// reference, against its registry and returns the resulting Lockfile.
+// A nil cli creates a client from the testcontainers Docker configuration.
+func Lock(ctx context.Context, cli DistributionClient, images map[string]string) (Lockfile, error) {
+	if cli == nil {
+		dockerClient, err := testcontainers.NewDockerClientWithOpts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer dockerClient.Close()
+		cli = dockerClient
+	}
+
+	lockfile := make(Lockfile)
+	var errs []error
+	for _, tag := range slices.Sorted(maps.Keys(images)) {
+		image := images[tag]
+		inspect, err := cli.DistributionInspect(ctx, image, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve digest of image %s for module %s: %w", image, tag, err))
+			continue
+		}
+		lockfile.Set(tag, image, inspect.Descriptor.Digest.String())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return lockfile, nil
+}
+
+// LockModule enforces the lockfile at path on every module of the stack,
+// rewriting each request image to its pinned digest at New time.
+// A module or image missing from the lockfile fails the stack.
+func LockModule(path string) fx.Option {
+	return fx.Provide(
+		fx.Annotate(
+			func() (StackCustomizer, error) {
+				lockfile, err := LoadLockfile(path)
+				if err != nil {
+					return nil, err
+				}
+				return func(tag string, req *testcontainers.GenericContainerRequest) error {
+					pinned, err := lockfile.Pin(tag, req.Image)
+					if err != nil {
+						return fmt.Errorf("%s: %w", path, err)
+					}
+					req.Image = pinned
+					return nil
+				}, nil
+			},
+			fx.ResultTags(`group:"stack_customizers"`),
+		),
+	)
+}
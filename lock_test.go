@@ -0,0 +1,123 @@
+package mockestra_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/narwhl/mockestra"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+const testDigest = "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+
+// fakeDistributionClient resolves every known image to its digest.
+type fakeDistributionClient map[string]string
+
+func (f fakeDistributionClient) DistributionInspect(ctx context.Context, ref, _ string) (registry.DistributionInspect, error) {
+	dgst, ok := f[ref]
+	if !ok {
+		return registry.DistributionInspect{}, errors.New("manifest unknown")
+	}
+	return registry.DistributionInspect{Descriptor: ocispec.Descriptor{Digest: digest.Digest(dgst)}}, nil
+}
+
+func TestLockfilePin(t *testing.T) {
+	lockfile := mockestra.Lockfile{}
+	lockfile.Set("postgres", "postgres:17-alpine", testDigest)
+	lockfile.Set("registry", "ghcr.io/example/registry:3", testDigest)
+	lockfile.Set("broken", "broken:1", "sha256:nope")
+
+	for _, tc := range []struct {
+		tag, image, expected, err string
+	}{
+		{tag: "postgres", image: "postgres:17-alpine", expected: "postgres@" + testDigest},
+		{tag: "registry", image: "ghcr.io/example/registry:3", expected: "ghcr.io/example/registry@" + testDigest},
+		{tag: "redis", image: "redis:8", err: "no entry for module redis"},
+		{tag: "postgres", image: "postgres:18-alpine", err: "pins module postgres to postgres:17-alpine but the stack requests postgres:18-alpine"},
+		{tag: "broken", image: "broken:1", err: "invalid digest"},
+	} {
+		pinned, err := lockfile.Pin(tc.tag, tc.image)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Pin(%s, %s): expected error containing %q, got %v", tc.tag, tc.image, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Pin(%s, %s) failed: %v", tc.tag, tc.image, err)
+		} else if pinned != tc.expected {
+			t.Errorf("Pin(%s, %s) = %s, expected %s", tc.tag, tc.image, pinned, tc.expected)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	cli := fakeDistributionClient{"postgres:17-alpine": testDigest}
+	lockfile, err := mockestra.Lock(t.Context(), cli, map[string]string{"postgres": "postgres:17-alpine"})
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), mockestra.LockfileName)
+	if err := lockfile.Save(path); err != nil {
+		t.Fatalf("failed to save lockfile: %v", err)
+	}
+	loaded, err := mockestra.LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("failed to load lockfile: %v", err)
+	}
+	if loaded["postgres"]["postgres:17-alpine"] != testDigest {
+		t.Errorf("unexpected lockfile content %v", loaded)
+	}
+
+	if _, err := mockestra.Lock(t.Context(), cli, map[string]string{"redis": "redis:8"}); err == nil {
+		t.Error("expected unresolvable image to fail")
+	}
+}
+
+func TestLockModule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), mockestra.LockfileName)
+	lockfile := mockestra.Lockfile{}
+	lockfile.Set("first", "first:1", testDigest)
+	if err := lockfile.Save(path); err != nil {
+		t.Fatalf("failed to save lockfile: %v", err)
+	}
+	newRequest := func(img string) func() *testcontainers.GenericContainerRequest {
+		return func() *testcontainers.GenericContainerRequest {
+			return &testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{Image: img},
+			}
+		}
+	}
+
+	var req *testcontainers.GenericContainerRequest
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		mockestra.BuildContainerModule("first", fx.Provide(fx.Annotate(newRequest("first:1"), fx.ResultTags(`name:"first"`))))(),
+		mockestra.LockModule(path),
+		fx.Populate(fx.Annotate(&req, fx.ParamTags(`name:"first"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+	if req.Image != "first@"+testDigest {
+		t.Errorf("expected image to be pinned, got %s", req.Image)
+	}
+
+	err := fx.New(
+		fx.NopLogger,
+		mockestra.BuildContainerModule("second", fx.Provide(fx.Annotate(newRequest("second:1"), fx.ResultTags(`name:"second"`))))(),
+		mockestra.LockModule(path),
+		fx.Invoke(fx.Annotate(func(*testcontainers.GenericContainerRequest) {}, fx.ParamTags(`name:"second"`))),
+	).Err()
+	if err == nil || !strings.Contains(err.Error(), "no entry for module second") {
+		t.Errorf("expected missing lockfile entry to fail the stack, got %v", err)
+	}
+}
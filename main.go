@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"maps"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/testcontainers/testcontainers-go"
@@ -24,13 +25,67 @@ type ContainerModule func(values ...testcontainers.ContainerCustomizer) fx.Optio
 // higher order function for hooking function after the container is ready.
 type ContainerPostReadyHook func(endpoints map[string]string) error
 
+// StackCustomizer is a representation of a customization applied to the request
+// of every module in the stack, after the module's own options. It receives the
+// module tag alongside the request so that it can tell modules apart.
+type StackCustomizer func(tag string, req *testcontainers.GenericContainerRequest) error
+
+// StackCustomizers supplies fns to the `group:"stack_customizers"` value group
+// consumed by every module built with BuildContainerModule.
+func StackCustomizers(fns ...StackCustomizer) fx.Option {
+	var opts []fx.Option
+	for _, fn := range fns {
+		opts = append(opts, fx.Supply(
+			fx.Annotate(
+				fn,
+				fx.ResultTags(`group:"stack_customizers"`),
+			),
+		))
+	}
+	return fx.Options(opts...)
+}
+
 // BuildContainerModule decorates the fx.Option with the testcontainers.ContainerCustomizer.
 // {label} is for tagging incoming testcontainers.ContainerCustomizer with ResultTags.
+// The module's request, named after {label}, is labeled for Reap, passed
+// through the stack wide StackCustomizer group and collected into the
+// `group:"requests"` value group for stack wide tooling such as Prefetch. A Requirement for {label} is
+// provided to the `group:"requirements"` value group for ValidateModule.
 func BuildContainerModule(label string, options ...fx.Option) ContainerModule {
 	return func(values ...testcontainers.ContainerCustomizer) fx.Option {
 		// Create a copy of the base options to avoid mutating the shared slice
-		result := make([]fx.Option, len(options), len(options)+len(values))
+		result := make([]fx.Option, len(options), len(options)+len(values)+3)
 		copy(result, options)
+		result = append(result,
+			fx.Provide(requirement(label)),
+			fx.Decorate(
+				fx.Annotate(
+					func(req *testcontainers.GenericContainerRequest, prefix string, customizers []StackCustomizer) (*testcontainers.GenericContainerRequest, error) {
+						if req.Labels == nil {
+							req.Labels = make(map[string]string)
+						}
+						maps.Copy(req.Labels, Labels(prefix, label))
+						for _, customize := range customizers {
+							if err := customize(label, req); err != nil {
+								return nil, err
+							}
+						}
+						return req, nil
+					},
+					fx.ParamTags(fmt.Sprintf(`name:"%s"`, label), `name:"prefix" optional:"true"`, `group:"stack_customizers"`),
+					fx.ResultTags(fmt.Sprintf(`name:"%s"`, label)),
+				),
+			),
+			fx.Provide(
+				fx.Annotate(
+					func(req *testcontainers.GenericContainerRequest) *testcontainers.GenericContainerRequest {
+						return req
+					},
+					fx.ParamTags(fmt.Sprintf(`name:"%s"`, label)),
+					fx.ResultTags(`group:"requests"`),
+				),
+			),
+		)
 
 		for _, v := range values {
 			if v == nil {
@@ -0,0 +1,177 @@
+package mockestra
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MigrationsTable records the versions applied by MigrateFS.
+const MigrationsTable = "schema_migrations"
+
+// Migration is an up migration file named `{version}_{title}.up.sql`,
+// where version is a number such as 1 or a timestamp like 20240102150405.
+type Migration struct {
+	Version uint64
+	Path    string
+}
+
+// Migrations lists the up migrations in dir of fsys, ordered by version.
+// Files of other kinds, such as down migrations, are ignored.
+func Migrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(strings.TrimSuffix(name, ".up.sql"), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Path:    path.Join(dir, name),
+		})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migrations[i-1].Path, migrations[i].Path, migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// MigrateFS applies the up migrations in dir of fsys that the database at
+// dsn has not recorded in MigrationsTable yet, in order. Each migration runs
+// in a transaction along with its record, so a failing one leaves nothing
+// behind; statements refusing to run in a transaction are not supported.
+func MigrateFS(ctx context.Context, dsn string, fsys fs.FS, dir string) error {
+	migrations, err := Migrations(fsys, dir)
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database while running migrations: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	table := pgx.Identifier{MigrationsTable}.Sanitize()
+	if _, err := conn.Exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, applied_at timestamptz NOT NULL DEFAULT now())", table)); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", MigrationsTable, err)
+	}
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT version FROM %s", table))
+	if err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	applied, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+
+	var count int
+	for _, m := range migrations {
+		if slices.Contains(applied, int64(m.Version)) {
+			continue
+		}
+		statements, err := fs.ReadFile(fsys, m.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", m.Path, err)
+		}
+		err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, string(statements)); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version) VALUES ($1)", table), int64(m.Version))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.Path, err)
+		}
+		count++
+	}
+	slog.Info("migrations applied", "applied", count, "total", len(migrations))
+	return nil
+}
+
+// SeedFS loads every file of fsys into the database at dsn in a single
+// transaction, walking fsys in lexical order. SQL files are run as is and
+// CSV files are copied into the table named after the file, such as
+// `users.csv` or `billing.invoices.csv`, with columns taken from their
+// header row.
+func SeedFS(ctx context.Context, dsn string, fsys fs.FS) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database while seeding: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			switch path.Ext(name) {
+			case ".sql":
+				statements, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					return fmt.Errorf("failed to read seed %s: %w", name, err)
+				}
+				if _, err := tx.Exec(ctx, string(statements)); err != nil {
+					return fmt.Errorf("failed to run seed %s: %w", name, err)
+				}
+			case ".csv":
+				if err := copyCSV(ctx, tx, fsys, name); err != nil {
+					return fmt.Errorf("failed to copy seed %s: %w", name, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// copyCSV copies the CSV file name of fsys into the table named after it.
+func copyCSV(ctx context.Context, tx pgx.Tx, fsys fs.FS, name string) error {
+	header, err := func() ([]string, error) {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return csv.NewReader(f).Read()
+	}()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = pgx.Identifier{strings.TrimSpace(column)}.Sanitize()
+	}
+	table := pgx.Identifier(strings.Split(strings.TrimSuffix(path.Base(name), ".csv"), ".")).Sanitize()
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = tx.Conn().PgConn().CopyFrom(ctx, f, fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER true)", table, strings.Join(columns, ", ")))
+	return err
+}
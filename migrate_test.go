@@ -0,0 +1,48 @@
+package mockestra_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/narwhl/mockestra"
+)
+
+func TestMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/10_add_index.up.sql":    {Data: []byte("CREATE INDEX ON items (name);")},
+		"migrations/10_add_index.down.sql":  {Data: []byte("DROP INDEX items_name_idx;")},
+		"migrations/2_create_items.up.sql":  {Data: []byte("CREATE TABLE items (name TEXT);")},
+		"migrations/1_create_schema.up.sql": {Data: []byte("CREATE SCHEMA app;")},
+		"migrations/README.md":              {Data: []byte("migrations")},
+	}
+	migrations, err := mockestra.Migrations(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	expected := []mockestra.Migration{
+		{Version: 1, Path: "migrations/1_create_schema.up.sql"},
+		{Version: 2, Path: "migrations/2_create_items.up.sql"},
+		{Version: 10, Path: "migrations/10_add_index.up.sql"},
+	}
+	if len(migrations) != len(expected) {
+		t.Fatalf("expected %d migrations, got %v", len(expected), migrations)
+	}
+	for i := range expected {
+		if migrations[i] != expected[i] {
+			t.Errorf("expected migration %d to be %v, got %v", i, expected[i], migrations[i])
+		}
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"unversioned": {"migrations/create_items.up.sql": {}},
+		"duplicate": {
+			"migrations/1_create_items.up.sql":  {},
+			"migrations/01_create_users.up.sql": {},
+		},
+		"missing": {},
+	} {
+		if _, err := mockestra.Migrations(fsys, "migrations"); err == nil {
+			t.Errorf("expected %s migrations to fail", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// newClient returns a client for the server at endpoint, authenticated with
+// the root credentials configured on req, or the image defaults.
+func newClient(req *testcontainers.GenericContainerRequest, endpoint string) (*minio.Client, error) {
+	var creds *credentials.Credentials
+	if accessKeyID, ok := req.Env["MINIO_ROOT_USER"]; ok {
+		if secretAccessKey, ok := req.Env["MINIO_ROOT_PASSWORD"]; ok {
+			creds = credentials.NewStaticV4(accessKeyID, secretAccessKey, "")
+		} else {
+			return nil, fmt.Errorf("missing MINIO_ROOT_PASSWORD environment variable")
+		}
+	} else {
+		creds = credentials.NewStaticV4("minioadmin", "minioadmin", "")
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio client: %w", err)
+	}
+	return client, nil
+}
+
+type ClientParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"minio"`
+	Container testcontainers.Container                `name:"minio"`
+}
+
+// NewClient returns a client connected to the container with its root credentials.
+// The client holds no connection of its own, so there is nothing to close on stop.
+func NewClient(p ClientParams) (*minio.Client, error) {
+	endpoint, err := p.Container.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	return newClient(p.Request, endpoint)
+}
+
+// ClientModule provides a *minio.Client named "minio" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"minio"`),
+	),
+)
@@ -0,0 +1,38 @@
+package minio
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// External binds the module to an already running server at endpoint, given
+// as host:port, authenticated with credentials, instead of launching a container.
+func External(endpoint string, credentials MinioCredentials) fx.Option {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fx.Error(fmt.Errorf("invalid external %s endpoint: %w", ContainerPrettyName, err))
+	}
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name: host,
+			Env:  make(map[string]string),
+		},
+	}
+	if err := WithObjectStorageCredentials(credentials).Customize(req); err != nil {
+		return fx.Error(err)
+	}
+	return mockestra.External(
+		Tag,
+		req,
+		&mockestra.ExternalContainer{
+			ServiceName:  Tag,
+			Address:      host,
+			ServicePorts: map[nat.Port]string{Port: port},
+		},
+	)
+}
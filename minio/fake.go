@@ -0,0 +1,48 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+)
+
+// actualizeFake serves the module from an in-memory S3 fake instead of
+// a container, for stacks switched with mockestra.InProcess.
+// The fake accepts any credentials.
+func actualizeFake(p ContainerParams) (Result, error) {
+	addr, shutdown, err := mockestra.ServeFake(gofakes3.New(s3mem.New()).Server())
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while starting in-process %s: %w", ContainerPrettyName, err)
+	}
+	c, err := mockestra.FakeContainer(Tag, addr, Port)
+	if err == nil {
+		err = mockestra.RunPostReadyHooks(context.Background(), p.Request, c)
+	}
+	if err != nil {
+		shutdown(context.Background())
+		return Result{}, err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			slog.Info(fmt.Sprintf("%s in-process fake is running at", ContainerPrettyName), "addr", addr)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := shutdown(ctx); err != nil {
+				slog.Warn(fmt.Sprintf("an error occurred while stopping %s in-process fake", ContainerPrettyName), "error", err)
+				return err
+			}
+			slog.Info(fmt.Sprintf("%s in-process fake is stopped", ContainerPrettyName))
+			return nil
+		},
+	})
+	return Result{
+		Container:      c,
+		ContainerGroup: c,
+	}, nil
+}
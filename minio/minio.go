@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/narwhl/mockestra"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -37,22 +36,9 @@ func WithBucket(bucketName string) testcontainers.CustomizeRequestOption {
 					if err != nil {
 						return fmt.Errorf("encounter error getting endpoint while creating bucket: %w", err)
 					}
-					var creds *credentials.Credentials
-					if accessKeyID, ok := req.Env["MINIO_ROOT_USER"]; ok {
-						if secretAccessKey, ok := req.Env["MINIO_ROOT_PASSWORD"]; ok {
-							creds = credentials.NewStaticV4(accessKeyID, secretAccessKey, "")
-						} else {
-							return fmt.Errorf("missing MINIO_ROOT_PASSWORD environment variable")
-						}
-					} else {
-						creds = credentials.NewStaticV4("minioadmin", "minioadmin", "")
-					}
-					client, err := minio.New(endpoint, &minio.Options{
-						Creds:  creds,
-						Secure: false,
-					})
+					client, err := newClient(req, endpoint)
 					if err != nil {
-						return fmt.Errorf("failed to create minio client: %w", err)
+						return err
 					}
 					exists, err := client.BucketExists(ctx, bucketName)
 					if err != nil {
@@ -121,6 +107,7 @@ type ContainerParams struct {
 	fx.In
 	Lifecycle fx.Lifecycle
 	Request   *testcontainers.GenericContainerRequest `name:"minio"`
+	InProcess bool                                    `name:"in_process" optional:"true"`
 }
 
 type Result struct {
@@ -130,6 +117,9 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
+	if p.InProcess {
+		return actualizeFake(p)
+	}
 	c, err := testcontainers.GenericContainer(context.Background(), *p.Request)
 	if err != nil {
 		return Result{}, fmt.Errorf("failed to create %s container: %w", ContainerPrettyName, err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/narwhl/mockestra"
 	container "github.com/narwhl/mockestra/minio"
 	"github.com/testcontainers/testcontainers-go"
 	"go.uber.org/fx"
@@ -111,3 +112,71 @@ func TestMinioModule_WithBucket(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestMinioModule_ClientModule(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"minio_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("minio-client-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client *minio.Client `name:"minio"`
+		}) {
+			if _, err := params.Client.ListBuckets(t.Context()); err != nil {
+				t.Errorf("failed to list buckets: %v", err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
+
+func TestMinioModule_InProcess(t *testing.T) {
+	expectedBucket := "test-bucket"
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"minio_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("minio-inprocess-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		mockestra.InProcess(),
+		container.Module(
+			container.WithBucket(expectedBucket),
+		),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client *minio.Client `name:"minio"`
+		}) {
+			exists, err := params.Client.BucketExists(t.Context(), expectedBucket)
+			if err != nil {
+				t.Errorf("failed to check bucket: %v", err)
+			}
+			if !exists {
+				t.Errorf("expected bucket '%s' to be created", expectedBucket)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
@@ -0,0 +1,52 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Container testcontainers.Container `name:"nats"`
+}
+
+type ClientResult struct {
+	fx.Out
+	Conn      *natsgo.Conn        `name:"nats"`
+	JetStream jetstream.JetStream `name:"nats"`
+}
+
+// NewClient returns a connection to the container and its JetStream context.
+// TLS is configured from the container labels like the PostReady hooks, so
+// servers set up through WithTLS or WithCA are verified accordingly.
+// The connection is drained when the app stops.
+func NewClient(p ClientParams) (ClientResult, error) {
+	nc, err := connectToNATS(context.Background(), p.Container)
+	if err != nil {
+		return ClientResult{}, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return ClientResult{}, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return nc.Drain()
+		},
+	})
+	return ClientResult{
+		Conn:      nc,
+		JetStream: js,
+	}, nil
+}
+
+// ClientModule provides a *nats.Conn and a jetstream.JetStream named "nats"
+// connected to the container.
+var ClientModule = fx.Provide(NewClient)
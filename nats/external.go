@@ -0,0 +1,46 @@
+package nats
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// External binds the module to an already running server at serverURL,
+// e.g. nats://nats:4222 or tls://nats:4222, instead of launching a container.
+// A tls:// server is verified against the system roots by the hooks and
+// the client module.
+func External(serverURL string) fx.Option {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return fx.Error(fmt.Errorf("invalid external %s URL: %w", ContainerPrettyName, err))
+	}
+	port := u.Port()
+	if port == "" {
+		_, port = nat.SplitProtoPort(Port)
+	}
+	labels := make(map[string]string)
+	if u.Scheme == "tls" {
+		labels[tlsEnabledLabel] = "true"
+	}
+	return mockestra.External(
+		Tag,
+		&testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Name:   u.Hostname(),
+				Env:    make(map[string]string),
+				Labels: labels,
+			},
+		},
+		&mockestra.ExternalContainer{
+			ServiceName:  Tag,
+			Address:      u.Hostname(),
+			ServicePorts: map[nat.Port]string{Port: port},
+			Labels:       labels,
+		},
+	)
+}
@@ -0,0 +1,86 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/narwhl/mockestra"
+	"github.com/nats-io/nats-server/v2/server"
+	"go.uber.org/fx"
+)
+
+// actualizeFake serves the module from an embedded NATS server with
+// JetStream instead of a container, for stacks switched with mockestra.InProcess.
+// The JetStream domain and storage directory options carry over, while TLS
+// is not supported.
+func actualizeFake(p ContainerParams) (Result, error) {
+	if p.Request.Labels[tlsEnabledLabel] == "true" {
+		return Result{}, fmt.Errorf("the in-process %s fake does not serve TLS, leave out either TLS or mockestra.InProcess", ContainerPrettyName)
+	}
+	opts := &server.Options{
+		Host:      mockestra.LoopbackAddress,
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		NoSigs:    true,
+		NoLog:     true,
+	}
+	if i := slices.Index(p.Request.Cmd, "-jetstream_domain"); i >= 0 && i+1 < len(p.Request.Cmd) {
+		opts.JetStreamDomain = p.Request.Cmd[i+1]
+	}
+	// a temporary storage directory is removed along with the server
+	var tempDir string
+	if i := slices.Index(p.Request.Cmd, "-sd"); i >= 0 && i+1 < len(p.Request.Cmd) {
+		opts.StoreDir = p.Request.Cmd[i+1]
+	} else {
+		dir, err := os.MkdirTemp("", "mockestra-nats-*")
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to create JetStream storage directory: %w", err)
+		}
+		tempDir, opts.StoreDir = dir, dir
+	}
+
+	ns, err := server.NewServer(opts)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return Result{}, fmt.Errorf("an error occurred while starting in-process %s: %w", ContainerPrettyName, err)
+	}
+	shutdown := func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+		if tempDir != "" {
+			os.RemoveAll(tempDir)
+		}
+	}
+	ns.Start()
+	if !ns.ReadyForConnections(10 * time.Second) {
+		shutdown()
+		return Result{}, fmt.Errorf("in-process %s is not ready for connections", ContainerPrettyName)
+	}
+	c, err := mockestra.FakeContainer(Tag, ns.Addr().String(), Port)
+	if err == nil {
+		err = mockestra.RunPostReadyHooks(context.Background(), p.Request, c)
+	}
+	if err != nil {
+		shutdown()
+		return Result{}, err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			slog.Info(fmt.Sprintf("%s in-process fake is running", ContainerPrettyName), "client", ns.Addr().String())
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			shutdown()
+			slog.Info(fmt.Sprintf("%s in-process fake is stopped", ContainerPrettyName))
+			return nil
+		},
+	})
+	return Result{
+		Container:      c,
+		ContainerGroup: c,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package nats
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/docker/go-connections/nat"
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	natsgo "github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/testcontainers/testcontainers-go"
@@ -69,6 +71,7 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"nats_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"nats"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 var WithUsername = nats.WithUsername
@@ -161,6 +164,22 @@ func WithTLS(config TLSConfig) testcontainers.CustomizeRequestOption {
 	}
 }
 
+// WithCA enables TLS for the NATS server with a leaf certificate issued by ca.
+// The CA is mounted alongside, so PostReady hooks verify the server against it.
+func WithCA(ca *pki.CA) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		serverCert, err := ca.IssueServer(Tag, pki.ServerNames(req.Name, req.NetworkAliases)...)
+		if err != nil {
+			return fmt.Errorf("failed to issue %s server certificate: %w", ContainerPrettyName, err)
+		}
+		return WithTLS(TLSConfig{
+			CertReader: bytes.NewReader(serverCert.CertPEM),
+			KeyReader:  bytes.NewReader(serverCert.KeyPEM),
+			CAReader:   bytes.NewReader(ca.CertPEM),
+		})(req)
+	}
+}
+
 // WithJetStreamStorageDir configures the storage directory for JetStream
 func WithJetStreamStorageDir(dir string) testcontainers.CustomizeRequestOption {
 	return func(req *testcontainers.GenericContainerRequest) error {
@@ -389,6 +408,13 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		}
 	}
 
+	// Certificates passed explicitly through WithTLS take precedence over the stack CA
+	if p.CA != nil && r.Labels[tlsEnabledLabel] != "true" {
+		if err := WithCA(p.CA).Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
@@ -396,6 +422,7 @@ type ContainerParams struct {
 	fx.In
 	Lifecycle fx.Lifecycle
 	Request   *testcontainers.GenericContainerRequest `name:"nats"`
+	InProcess bool                                    `name:"in_process" optional:"true"`
 }
 
 type Result struct {
@@ -405,6 +432,9 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
+	if p.InProcess {
+		return actualizeFake(p)
+	}
 	c, err := testcontainers.GenericContainer(context.Background(), *p.Request)
 	if err != nil {
 		return Result{}, fmt.Errorf("failed to create %s container: %w", ContainerPrettyName, err)
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/narwhl/mockestra"
 	container "github.com/narwhl/mockestra/nats"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
@@ -773,3 +774,77 @@ func TestNATSWithMTLS(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestClientModule(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"nats_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("nats-client-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Conn      *nats.Conn          `name:"nats"`
+			JetStream jetstream.JetStream `name:"nats"`
+		}) {
+			if !params.Conn.IsConnected() {
+				t.Error("expected NATS connection to be established")
+			}
+			if _, err := params.JetStream.AccountInfo(t.Context()); err != nil {
+				t.Errorf("failed to query JetStream account info: %v", err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
+
+func TestInProcess(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"nats_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("nats-inprocess-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		mockestra.InProcess(),
+		container.Module(
+			container.WithStream(container.StreamConfig{
+				Name:     "ORDERS",
+				Subjects: []string{"ORDERS.*"},
+			}),
+		),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			JetStream jetstream.JetStream `name:"nats"`
+		}) {
+			ack, err := params.JetStream.Publish(t.Context(), "ORDERS.new", []byte("Order #123"))
+			if err != nil {
+				t.Fatalf("Failed to publish message: %v", err)
+			}
+			if ack.Stream != "ORDERS" {
+				t.Errorf("Expected message to be stored in ORDERS stream, got %s", ack.Stream)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
@@ -0,0 +1,57 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/openfga/go-sdk/credentials"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// newClient returns a client for the server at addr, authenticated with
+// the preshared key configured on req, if any.
+func newClient(req *testcontainers.GenericContainerRequest, addr string) (*client.OpenFgaClient, error) {
+	config := &client.ClientConfiguration{
+		ApiUrl: fmt.Sprintf("http://%s", addr),
+	}
+	if token := req.Env["OPENFGA_AUTHN_PRESHARED_KEYS"]; token != "" {
+		config.Credentials = &credentials.Credentials{
+			Method: credentials.CredentialsMethodApiToken,
+			Config: &credentials.Config{
+				ApiToken: token,
+			},
+		}
+	}
+	fgaClient, err := client.NewSdkClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client: %w", ContainerPrettyName, err)
+	}
+	return fgaClient, nil
+}
+
+type ClientParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"openfga"`
+	Container testcontainers.Container                `name:"openfga"`
+}
+
+// NewClient returns a client connected to the HTTP API of the container.
+// Set the store and model IDs handed to the WithAuthorizationModel callback
+// with SetStoreId and SetAuthorizationModelId.
+func NewClient(p ClientParams) (*client.OpenFgaClient, error) {
+	addr, err := p.Container.PortEndpoint(context.Background(), HttpPort, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	return newClient(p.Request, addr)
+}
+
+// ClientModule provides a *client.OpenFgaClient named "openfga" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"openfga"`),
+	),
+)
@@ -12,7 +12,6 @@ import (
 	"github.com/narwhl/mockestra"
 	openfga "github.com/openfga/go-sdk"
 	"github.com/openfga/go-sdk/client"
-	"github.com/openfga/go-sdk/credentials"
 	language "github.com/openfga/language/pkg/go/transformer"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -53,17 +52,9 @@ func WithAuthorizationModel(model string, cb callback) testcontainers.CustomizeR
 					if err != nil {
 						return fmt.Errorf("failed to transform due to %w", err)
 					}
-					fgaClient, err := client.NewSdkClient(&client.ClientConfiguration{
-						ApiUrl: fmt.Sprintf("http://%s", addr),
-						Credentials: &credentials.Credentials{
-							Method: credentials.CredentialsMethodApiToken,
-							Config: &credentials.Config{
-								ApiToken: req.Env["OPENFGA_AUTHN_PRESHARED_KEYS"],
-							},
-						},
-					})
+					fgaClient, err := newClient(req, addr)
 					if err != nil {
-						return fmt.Errorf("failed to create OpenFGA client: %w", err)
+						return err
 					}
 					writeAuthModelReq := openfga.NewWriteAuthorizationModelRequest(
 						jsonAuthModel.TypeDefinitions,
@@ -0,0 +1,255 @@
+package pgbouncer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/postgres"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/fx"
+)
+
+const (
+	Tag   = "pgbouncer"
+	Image = "edoburu/pgbouncer"
+	Port  = "5432/tcp"
+
+	ContainerPrettyName = "PgBouncer"
+
+	// Pool modes accepted by WithPoolMode
+	PoolModeSession     = "session"
+	PoolModeTransaction = "transaction"
+	PoolModeStatement   = "statement"
+
+	// settingLabelPrefix labels the request with the [pgbouncer] settings
+	// set by options, rendered into pgbouncer.ini once Postgres is running.
+	settingLabelPrefix = "mockestra.pgbouncer.setting."
+
+	configDir    = "/etc/pgbouncer"
+	configPath   = configDir + "/pgbouncer.ini"
+	userlistPath = configDir + "/userlist.txt"
+)
+
+// WithPoolMode sets when a server connection is handed back to the pool,
+// one of PoolModeSession, PoolModeTransaction or PoolModeStatement.
+// The default is PoolModeTransaction.
+func WithPoolMode(mode string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		switch mode {
+		case PoolModeSession, PoolModeTransaction, PoolModeStatement:
+		default:
+			return fmt.Errorf("invalid %s pool mode %q", ContainerPrettyName, mode)
+		}
+		return WithSetting("pool_mode", mode)(req)
+	}
+}
+
+// WithSetting sets key of the [pgbouncer] section of pgbouncer.ini, e.g.
+// WithSetting("max_prepared_statements", "0") to reproduce prepared
+// statement errors of transaction pooling.
+func WithSetting(key, value string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		req.Labels[settingLabelPrefix+key] = value
+		return nil
+	}
+}
+
+type RequestParams struct {
+	fx.In
+	Prefix  string                               `name:"prefix"`
+	Version string                               `name:"pgbouncer_version"`
+	Opts    []testcontainers.ContainerCustomizer `group:"pgbouncer"`
+}
+
+// New is a constructor that returns a testcontainers.GenericContainerRequest
+// and takes its group tagged testcontainers.ContainerCustomizer as options.
+// it is part of tri-phase process with Actualize and Run to create
+// a testcontainers.Container.
+func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
+	r := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:         fmt.Sprintf("mock-%s-%s", p.Prefix, Tag),
+			Image:        fmt.Sprintf("%s:%s", Image, p.Version),
+			ExposedPorts: []string{Port},
+			Env:          make(map[string]string),
+			Labels: map[string]string{
+				settingLabelPrefix + "pool_mode": PoolModeTransaction,
+			},
+			WaitingFor: wait.ForListeningPort(Port),
+		},
+		Started: true,
+	}
+
+	for _, opt := range p.Opts {
+		if err := opt.Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
+	return &r, nil
+}
+
+// config renders pgbouncer.ini, routing databases and any other database
+// name to the server at host and port. Users missing from userlist.txt are
+// looked up on the server through auth_query as the admin user username.
+func config(req *testcontainers.GenericContainerRequest, databases []mockestra.DatabaseLogin, host, port, username string) string {
+	var b strings.Builder
+	b.WriteString("[databases]\n")
+	for _, database := range databases {
+		fmt.Fprintf(&b, "%s = host=%s port=%s dbname=%s\n", quoteIdentifier(database.Database), host, port, quoteValue(database.Database))
+	}
+	fmt.Fprintf(&b, "* = host=%s port=%s\n", host, port)
+
+	settings := map[string]string{
+		"listen_addr":               "0.0.0.0",
+		"listen_port":               nat.Port(Port).Port(),
+		"auth_type":                 "scram-sha-256",
+		"auth_file":                 userlistPath,
+		"auth_user":                 username,
+		"auth_dbname":               databases[0].Database,
+		"admin_users":               username,
+		"ignore_startup_parameters": "extra_float_digits",
+	}
+	for label, value := range req.Labels {
+		if key, ok := strings.CutPrefix(label, settingLabelPrefix); ok {
+			settings[key] = value
+		}
+	}
+	b.WriteString("\n[pgbouncer]\n")
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		fmt.Fprintf(&b, "%s = %s\n", key, settings[key])
+	}
+	return b.String()
+}
+
+// userlist renders userlist.txt with the admin user of the server, listed
+// first, and the owners of its databases that have a password.
+func userlist(databases []mockestra.DatabaseLogin) string {
+	var (
+		b     strings.Builder
+		users []string
+	)
+	for _, database := range databases {
+		if database.Username == "" || database.Password == "" || slices.Contains(users, database.Username) {
+			continue
+		}
+		users = append(users, database.Username)
+		fmt.Fprintf(&b, "%s %s\n", quoteIdentifier(database.Username), quoteIdentifier(database.Password))
+	}
+	return b.String()
+}
+
+// quoteIdentifier quotes database names and userlist entries, doubling quotes.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteValue quotes connection string values, doubling quotes.
+func quoteValue(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+type ContainerParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"pgbouncer"`
+	Database  mockestra.SQLDatabase                   `name:"postgres"`
+}
+
+type Result struct {
+	fx.Out
+	Container      testcontainers.Container `name:"pgbouncer"`
+	ContainerGroup testcontainers.Container `group:"containers"`
+	// DSN connects to the database configured on Postgres through the
+	// pooler, reachable from the host.
+	DSN string `name:"pgbouncer"`
+}
+
+// Actualize is a constructor that returns a testcontainers.Container
+// it consumes previously instantiated testcontainers.GenericContainerRequest
+// as part of its inputs, alongside with other tag specified testcontainers.GenericContainerRequest
+// in order to reconcile its lifecycle dependencies before creating a testcontainers.Container.
+func Actualize(p ContainerParams) (Result, error) {
+	postgresHost, postgresPort, err := p.Database.Address(context.Background())
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get database address: %w", err)
+	}
+	username, password := p.Database.AdminCredentials()
+	databases := p.Database.Databases()
+	// the request is shared with the requests group, so the configuration
+	// only goes into the copy the container is created from
+	req := *p.Request
+	req.Files = append(slices.Clone(p.Request.Files),
+		testcontainers.ContainerFile{
+			Reader:            strings.NewReader(config(p.Request, databases, postgresHost, postgresPort, username)),
+			ContainerFilePath: configPath,
+			FileMode:          0o644,
+		},
+		testcontainers.ContainerFile{
+			Reader:            strings.NewReader(userlist(databases)),
+			ContainerFilePath: userlistPath,
+			FileMode:          0o644,
+		},
+	)
+
+	c, err := testcontainers.GenericContainer(context.Background(), req)
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while instantiating %s container: %w", ContainerPrettyName, err)
+	}
+	addr, err := c.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     addr,
+		Path:     "/" + databases[0].Database,
+		RawQuery: "sslmode=disable",
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			slog.Info(fmt.Sprintf("%s container is running", ContainerPrettyName), "addr", addr)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := c.Terminate(ctx)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("an error occurred while terminating %s container", ContainerPrettyName), "error", err)
+			} else {
+				slog.Info(fmt.Sprintf("%s container is terminated", ContainerPrettyName))
+			}
+			return err
+		},
+	})
+	return Result{
+		Container:      c,
+		ContainerGroup: c,
+		DSN:            dsn.String(),
+	}, nil
+}
+
+var WithPostReadyHook = mockestra.WithPostReadyHook
+
+var Module = mockestra.BuildContainerModule(
+	Tag,
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.ResultTags(`name:"pgbouncer"`),
+		),
+		Actualize,
+	),
+	mockestra.DependsOn(Tag, postgres.Tag),
+)
@@ -0,0 +1,87 @@
+package pgbouncer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	container "github.com/narwhl/mockestra/pgbouncer"
+	"github.com/narwhl/mockestra/postgres"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestWithPoolMode(t *testing.T) {
+	req := &testcontainers.GenericContainerRequest{}
+	if err := container.WithPoolMode(container.PoolModeSession)(req); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+	if err := container.WithPoolMode("connection")(req); err == nil {
+		t.Error("expected an unknown pool mode to fail")
+	}
+}
+
+func TestPgBouncerModule(t *testing.T) {
+	var dsn string
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"pgbouncer_version"`),
+			),
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"postgres_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("pgbouncer-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		postgres.Module(
+			postgres.WithUsername("testuser"),
+			postgres.WithPassword("testpass"),
+			postgres.WithDatabase("testdb"),
+			postgres.WithExtraDatabase("reports", "reports_user", "reports_pass"),
+		),
+		container.Module(
+			container.WithPoolMode(container.PoolModeTransaction),
+		),
+		fx.Populate(fx.Annotate(&dsn, fx.ParamTags(`name:"pgbouncer"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	conn, err := pgx.Connect(t.Context(), dsn)
+	if err != nil {
+		t.Fatalf("failed to connect through %s: %v", container.ContainerPrettyName, err)
+	}
+	defer conn.Close(context.Background())
+	var database string
+	if err := conn.QueryRow(t.Context(), "SELECT current_database() WHERE $1::int = 1", 1).Scan(&database); err != nil {
+		t.Fatalf("failed to query through %s: %v", container.ContainerPrettyName, err)
+	}
+	if database != "testdb" {
+		t.Errorf("expected testdb, got %s", database)
+	}
+
+	// owners of extra databases are listed in userlist.txt
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse dsn: %v", err)
+	}
+	config.User, config.Password, config.Database = "reports_user", "reports_pass", "reports"
+	reports, err := pgx.ConnectConfig(t.Context(), config)
+	if err != nil {
+		t.Fatalf("failed to connect to extra database through %s: %v", container.ContainerPrettyName, err)
+	}
+	defer reports.Close(context.Background())
+	if _, err := reports.Exec(t.Context(), "SELECT 1"); err != nil {
+		t.Errorf("failed to query extra database: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+)
+
+const (
+	Tag = "pki"
+
+	Organization = "Mockestra Test"
+
+	// Validity is how long issued CA and leaf certificates stay valid.
+	Validity = 365 * 24 * time.Hour
+)
+
+// CA is a per-stack certificate authority used to issue server and client
+// leaf certificates for TLS-capable modules.
+type CA struct {
+	Certificate *x509.Certificate
+	CertPEM     []byte
+	key         *ecdsa.PrivateKey
+}
+
+// KeyPair is a PEM encoded leaf certificate and its private key.
+type KeyPair struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// TLSCertificate parses the key pair into a tls.Certificate.
+func (k *KeyPair) TLSCertificate() (tls.Certificate, error) {
+	return tls.X509KeyPair(k.CertPEM, k.KeyPEM)
+}
+
+// NewCA generates a self-signed certificate authority with the given common name.
+func NewCA(commonName string) (*CA, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA private key: %w", err)
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{Organization},
+			CommonName:   commonName,
+		},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(Validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	return &CA{
+		Certificate: cert,
+		CertPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		key:         priv,
+	}, nil
+}
+
+// IssueServer issues a server leaf certificate valid for alias, localhost,
+// 127.0.0.1 and any additional DNS names or IP addresses in hosts.
+// The certificate also carries the client auth usage so that it can be
+// presented for mutual TLS between containers.
+func (ca *CA) IssueServer(alias string, hosts ...string) (*KeyPair, error) {
+	template := x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{Organization},
+			CommonName:   alias,
+		},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	seen := make(map[string]bool)
+	for _, host := range append([]string{alias, "localhost", mockestra.LoopbackAddress}, hosts...) {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	return ca.issue(&template)
+}
+
+// IssueClient issues a client leaf certificate with the given common name.
+func (ca *CA) IssueClient(commonName string) (*KeyPair, error) {
+	return ca.issue(&x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{Organization},
+			CommonName:   commonName,
+		},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+// CertPool returns a certificate pool containing only the CA certificate.
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+// ClientTLSConfig returns a tls.Config trusting the CA. When client is not nil,
+// it is presented as the client certificate for mutual TLS.
+func (ca *CA) ClientTLSConfig(client *KeyPair) (*tls.Config, error) {
+	config := &tls.Config{
+		RootCAs: ca.CertPool(),
+	}
+	if client != nil {
+		cert, err := client.TLSCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func (ca *CA) issue(template *x509.Certificate) (*KeyPair, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serialNumber
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(Validity)
+	template.BasicConstraintsValid = true
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &priv.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate for %s: %w", template.Subject.CommonName, err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	var certBuf bytes.Buffer
+	if err := pem.Encode(&certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: certDER}); err != nil {
+		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+	return &KeyPair{
+		CertPEM: certBuf.Bytes(),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
+
+// ServerNames returns the names a container is reachable by inside the stack,
+// namely its container name and any network aliases, for use as certificate SANs.
+func ServerNames(name string, networkAliases map[string][]string) []string {
+	names := []string{name}
+	for _, aliases := range networkAliases {
+		names = append(names, aliases...)
+	}
+	return names
+}
+
+type Params struct {
+	fx.In
+	Prefix string `name:"prefix"`
+}
+
+// New is a constructor that returns the stack wide CA. TLS-capable modules
+// consume it as an optional dependency and issue their leaf certificates from it.
+func New(p Params) (*CA, error) {
+	ca, err := NewCA(fmt.Sprintf("mock-%s CA", p.Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while creating %s certificate authority: %w", p.Prefix, err)
+	}
+	return ca, nil
+}
+
+var Module = fx.Provide(New)
@@ -0,0 +1,165 @@
+package pki_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/narwhl/mockestra/pki"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func parseLeaf(t *testing.T, certPEM []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestIssueServer(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+
+	keyPair, err := ca.IssueServer("postgres", "mock-test-postgres", "10.0.0.2", "postgres")
+	if err != nil {
+		t.Fatalf("failed to issue server certificate: %v", err)
+	}
+	if _, err := keyPair.TLSCertificate(); err != nil {
+		t.Fatalf("issued key pair does not load: %v", err)
+	}
+
+	cert := parseLeaf(t, keyPair.CertPEM)
+	for _, name := range []string{"postgres", "mock-test-postgres", "localhost"} {
+		if !slices.Contains(cert.DNSNames, name) {
+			t.Errorf("expected DNS SAN %q, got %v", name, cert.DNSNames)
+		}
+	}
+	if len(cert.DNSNames) != 3 {
+		t.Errorf("expected duplicate names to be dropped, got %v", cert.DNSNames)
+	}
+	for _, ip := range []string{"127.0.0.1", "10.0.0.2"} {
+		if !slices.ContainsFunc(cert.IPAddresses, func(addr net.IP) bool { return addr.Equal(net.ParseIP(ip)) }) {
+			t.Errorf("expected IP SAN %s, got %v", ip, cert.IPAddresses)
+		}
+	}
+
+	for _, host := range []string{"postgres", "localhost", "127.0.0.1"} {
+		if _, err := cert.Verify(x509.VerifyOptions{
+			DNSName: host,
+			Roots:   ca.CertPool(),
+		}); err != nil {
+			t.Errorf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+}
+
+func TestIssueClient(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	keyPair, err := ca.IssueClient("tester")
+	if err != nil {
+		t.Fatalf("failed to issue client certificate: %v", err)
+	}
+	cert := parseLeaf(t, keyPair.CertPEM)
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     ca.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Errorf("client certificate does not verify: %v", err)
+	}
+
+	config, err := ca.ClientTLSConfig(keyPair)
+	if err != nil {
+		t.Fatalf("failed to build client TLS config: %v", err)
+	}
+	if len(config.Certificates) != 1 {
+		t.Errorf("expected client certificate to be presented, got %d", len(config.Certificates))
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	serverKeyPair, err := ca.IssueServer("nats")
+	if err != nil {
+		t.Fatalf("failed to issue server certificate: %v", err)
+	}
+	serverCert, err := serverKeyPair.TLSCertificate()
+	if err != nil {
+		t.Fatalf("failed to load server certificate: %v", err)
+	}
+	clientKeyPair, err := ca.IssueClient("tester")
+	if err != nil {
+		t.Fatalf("failed to issue client certificate: %v", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    ca.CertPool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.(*tls.Conn).Handshake()
+	}()
+
+	config, err := ca.ClientTLSConfig(clientKeyPair)
+	if err != nil {
+		t.Fatalf("failed to build client TLS config: %v", err)
+	}
+	conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+	if err != nil {
+		t.Fatalf("mutual TLS handshake failed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestModule(t *testing.T) {
+	var ca *pki.CA
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(fx.Annotate(
+			"pki-test",
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		pki.Module,
+		fx.Populate(&ca),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	if ca == nil {
+		t.Fatal("expected CA to be provided")
+	}
+	if ca.Certificate.Subject.CommonName != "mock-pki-test CA" {
+		t.Errorf("unexpected CA common name %q", ca.Certificate.Subject.CommonName)
+	}
+	if !ca.Certificate.IsCA {
+		t.Error("expected CA certificate to be a CA")
+	}
+}
@@ -0,0 +1,145 @@
+package mockestra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// hostPortLabelPrefix labels a container with the host ports pinned by WithHostPort.
+const hostPortLabelPrefix = "mockestra.host_port."
+
+// hostPortClaims tracks host ports pinned by WithHostPort across every stack
+// in the process, keyed by host port with the claiming container name as value.
+var hostPortClaims = struct {
+	sync.Mutex
+	owners map[int]string
+}{owners: make(map[int]string)}
+
+// WithHostPort publishes containerPort on a fixed hostPort instead of a random
+// one, for browser based tests or OAuth redirect URIs that need stable addresses,
+// e.g. hydra.Module(mockestra.WithHostPort(hydra.Port, 14444)).
+//
+// Conflicts are reported when the option is applied rather than surfacing as a
+// Docker error at container creation: mapping a host port twice, a host port
+// another container of the process already pinned, or a host port that is
+// already bound by another process all fail with an error naming the port.
+// The host port is claimed for the process right before the container is
+// created and released once it is terminated, so that requests which are never
+// actualized, e.g. on a validation or fx graph error, hold no claim.
+func WithHostPort(containerPort string, hostPort int) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		port, err := nat.NewPort(nat.SplitProtoPort(containerPort))
+		if err != nil {
+			return fmt.Errorf("invalid container port %q: %w", containerPort, err)
+		}
+		if hostPort <= 0 || hostPort > 65535 {
+			return fmt.Errorf("invalid host port %d for %s", hostPort, containerPort)
+		}
+		label := fmt.Sprintf("%s%d", hostPortLabelPrefix, hostPort)
+		if mapped, ok := req.Labels[label]; ok {
+			return fmt.Errorf("host port %d is mapped twice for %s, to %s and %s", hostPort, req.Name, mapped, port)
+		}
+		if err := checkHostPort(hostPort, req.Name); err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(hostPort)))
+		if err != nil {
+			return fmt.Errorf("host port %d for %s %s is already in use: %w", hostPort, req.Name, containerPort, err)
+		}
+		listener.Close()
+
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		req.Labels[label] = string(port)
+		if !slices.Contains(req.ExposedPorts, string(port)) {
+			req.ExposedPorts = append(req.ExposedPorts, string(port))
+		}
+
+		// Setting a modifier replaces the testcontainers default one, which
+		// carries over the deprecated host config fields of the request.
+		previous := req.HostConfigModifier
+		containerRequest := &req.ContainerRequest
+		req.HostConfigModifier = func(hostConfig *container.HostConfig) {
+			if previous != nil {
+				previous(hostConfig)
+			} else {
+				applyRequestHostConfig(containerRequest, hostConfig)
+			}
+			if hostConfig.PortBindings == nil {
+				hostConfig.PortBindings = make(nat.PortMap)
+			}
+			hostConfig.PortBindings[port] = []nat.PortBinding{{HostPort: strconv.Itoa(hostPort)}}
+		}
+
+		owner := req.Name
+		req.LifecycleHooks = append(req.LifecycleHooks, testcontainers.ContainerLifecycleHooks{
+			PreCreates: []testcontainers.ContainerRequestHook{
+				func(ctx context.Context, req testcontainers.ContainerRequest) error {
+					return claimHostPort(hostPort, owner)
+				},
+			},
+			PostTerminates: []testcontainers.ContainerHook{
+				func(ctx context.Context, c testcontainers.Container) error {
+					releaseHostPort(hostPort, owner)
+					return nil
+				},
+			},
+		})
+		return nil
+	}
+}
+
+// checkHostPort reports whether hostPort is claimed by another container than owner.
+func checkHostPort(hostPort int, owner string) error {
+	hostPortClaims.Lock()
+	defer hostPortClaims.Unlock()
+	return checkHostPortLocked(hostPort, owner)
+}
+
+func checkHostPortLocked(hostPort int, owner string) error {
+	// the same container may claim its port again when a stack is rebuilt
+	if current, ok := hostPortClaims.owners[hostPort]; ok && current != owner {
+		return fmt.Errorf("host port %d requested by %s is already claimed by %s", hostPort, owner, current)
+	}
+	return nil
+}
+
+func claimHostPort(hostPort int, owner string) error {
+	hostPortClaims.Lock()
+	defer hostPortClaims.Unlock()
+	if err := checkHostPortLocked(hostPort, owner); err != nil {
+		return err
+	}
+	hostPortClaims.owners[hostPort] = owner
+	return nil
+}
+
+func releaseHostPort(hostPort int, owner string) {
+	hostPortClaims.Lock()
+	defer hostPortClaims.Unlock()
+	if hostPortClaims.owners[hostPort] == owner {
+		delete(hostPortClaims.owners, hostPort)
+	}
+}
+
+// applyRequestHostConfig mirrors the testcontainers default host config modifier.
+func applyRequestHostConfig(req *testcontainers.ContainerRequest, hostConfig *container.HostConfig) {
+	hostConfig.AutoRemove = req.AutoRemove
+	hostConfig.CapAdd = req.CapAdd
+	hostConfig.CapDrop = req.CapDrop
+	hostConfig.Binds = req.Binds
+	hostConfig.ExtraHosts = req.ExtraHosts
+	hostConfig.NetworkMode = req.NetworkMode
+	hostConfig.Resources = req.Resources
+	hostConfig.Privileged = req.Privileged
+	hostConfig.ShmSize = req.ShmSize
+}
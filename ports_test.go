@@ -0,0 +1,144 @@
+package mockestra_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// freePort returns a host port that is currently unused.
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to allocate a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func newRequest(name string) *testcontainers.GenericContainerRequest {
+	return &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:         name,
+			ExposedPorts: []string{"4444/tcp"},
+			Privileged:   true,
+		},
+	}
+}
+
+func create(t *testing.T, req *testcontainers.GenericContainerRequest) error {
+	t.Helper()
+	for _, hooks := range req.LifecycleHooks {
+		for _, hook := range hooks.PreCreates {
+			if err := hook(context.Background(), req.ContainerRequest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func terminate(t *testing.T, req *testcontainers.GenericContainerRequest) {
+	t.Helper()
+	for _, hooks := range req.LifecycleHooks {
+		for _, hook := range hooks.PostTerminates {
+			if err := hook(context.Background(), nil); err != nil {
+				t.Fatalf("PostTerminates hook failed: %v", err)
+			}
+		}
+	}
+}
+
+func TestWithHostPort(t *testing.T) {
+	hostPort := freePort(t)
+	req := newRequest("mock-ports-test-hydra")
+
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(req); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+	t.Cleanup(func() { terminate(t, req) })
+	if err := create(t, req); err != nil {
+		t.Fatalf("PreCreates hook failed: %v", err)
+	}
+
+	if req.HostConfigModifier == nil {
+		t.Fatal("expected HostConfigModifier to be set")
+	}
+	hostConfig := &container.HostConfig{}
+	req.HostConfigModifier(hostConfig)
+
+	bindings := hostConfig.PortBindings[nat.Port("4444/tcp")]
+	if len(bindings) != 1 || bindings[0].HostPort != strconv.Itoa(hostPort) {
+		t.Errorf("expected 4444/tcp to be bound to %d, got %v", hostPort, bindings)
+	}
+	if !hostConfig.Privileged {
+		t.Error("expected the request host config fields to be carried over")
+	}
+
+	if err := mockestra.WithHostPort("4445/tcp", hostPort).Customize(req); err == nil || !strings.Contains(err.Error(), "mapped twice") {
+		t.Errorf("expected mapping the same host port twice to fail, got %v", err)
+	}
+
+	other := newRequest("mock-ports-test-kratos")
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(other); err == nil || !strings.Contains(err.Error(), "already claimed by mock-ports-test-hydra") {
+		t.Errorf("expected claiming a pinned host port to fail, got %v", err)
+	}
+
+	terminate(t, req)
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(other); err != nil {
+		t.Errorf("expected host port to be released after termination, got %v", err)
+	}
+	terminate(t, other)
+}
+
+func TestWithHostPortNotCreated(t *testing.T) {
+	hostPort := freePort(t)
+
+	// a request dropped before creation, e.g. on a validation error
+	dropped := newRequest("mock-ports-test-dropped")
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(dropped); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+
+	req := newRequest("mock-ports-test-created")
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(req); err != nil {
+		t.Fatalf("expected a request that was never created to hold no claim, got %v", err)
+	}
+	if err := create(t, req); err != nil {
+		t.Fatalf("PreCreates hook failed: %v", err)
+	}
+	t.Cleanup(func() { terminate(t, req) })
+
+	if err := create(t, dropped); err == nil || !strings.Contains(err.Error(), "already claimed by mock-ports-test-created") {
+		t.Errorf("expected creating a container on a claimed host port to fail, got %v", err)
+	}
+}
+
+func TestWithHostPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	hostPort := listener.Addr().(*net.TCPAddr).Port
+
+	req := newRequest("mock-ports-test-in-use")
+	err = mockestra.WithHostPort("4444/tcp", hostPort).Customize(req)
+	if err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("expected an in use error, got %v", err)
+	}
+
+	listener.Close()
+	if err := mockestra.WithHostPort("4444/tcp", hostPort).Customize(req); err != nil {
+		t.Errorf("expected a failed claim to be released, got %v", err)
+	}
+	terminate(t, req)
+}
@@ -0,0 +1,388 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+const (
+	// changeCaptureLabel lists the tables captured by WithChangeCapture,
+	// comma separated, or is empty to capture every table.
+	changeCaptureLabel = "mockestra.postgres.change_capture"
+
+	// changePublication is the publication ChangeCapture streams from.
+	changePublication = "mockestra_changes"
+
+	// changeBuffer is how many changes are held until they are consumed,
+	// before streaming stalls.
+	changeBuffer = 1024
+
+	// standbyStatusInterval keeps the server from timing out the stream.
+	standbyStatusInterval = 10 * time.Second
+)
+
+// ChangeOperation is the kind of row change captured by ChangeCapture.
+type ChangeOperation string
+
+const (
+	ChangeInsert ChangeOperation = "insert"
+	ChangeUpdate ChangeOperation = "update"
+	ChangeDelete ChangeOperation = "delete"
+)
+
+// Change is a row change decoded from the logical replication stream.
+type Change struct {
+	Operation ChangeOperation
+	Schema    string
+	Table     string
+	// Row is the new row of inserts and updates, or the replica identity
+	// of the deleted row, usually its primary key. Values are decoded into
+	// the Go types of pgx, nil for NULL. Unchanged TOAST values are left out.
+	Row map[string]any
+	// OldRow is the replica identity of the updated row when the update
+	// changed it, or the whole old row under REPLICA IDENTITY FULL.
+	OldRow     map[string]any
+	CommitTime time.Time
+}
+
+// WithChangeCapture turns on logical decoding on the server, so that the
+// ChangeCapture of the container streams row changes of tables, such as
+// "outbox" or "billing.invoices", or of every table when none is given.
+func WithChangeCapture(tables ...string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		if _, ok := req.Labels[changeCaptureLabel]; !ok {
+			if len(req.Cmd) == 0 {
+				req.Cmd = []string{"postgres"}
+			}
+			req.Cmd = append(req.Cmd, "-c", "wal_level=logical")
+		}
+		req.Labels[changeCaptureLabel] = strings.Join(tables, ",")
+		return nil
+	}
+}
+
+// ChangeCapture streams the row changes of the tables set by WithChangeCapture
+// through a temporary replication slot decoded with pgoutput by pglogrepl. Changes
+// committed once the ChangeCapture is provided are captured.
+type ChangeCapture struct {
+	conn      *pgconn.PgConn
+	slot      string
+	changes   chan Change
+	relations map[uint32]*pglogrepl.RelationMessage
+	types     *pgtype.Map
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error
+}
+
+type ChangeCaptureParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+}
+
+// NewChangeCapture publishes the tables set by WithChangeCapture and creates
+// the replication slot of the container, streaming changes while the app runs.
+func NewChangeCapture(p ChangeCaptureParams) (*ChangeCapture, error) {
+	tables, ok := p.Request.Labels[changeCaptureLabel]
+	if !ok {
+		return nil, fmt.Errorf("%s change capture requires the WithChangeCapture option", ContainerPrettyName)
+	}
+	ctx := context.Background()
+	addr, err := p.Container.PortEndpoint(ctx, Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	config, err := pgconn.ParseConfig(adminConnectionString(p.Request, addr, defaultDatabase(p.Request)))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while parsing %s connection string: %w", ContainerPrettyName, err)
+	}
+	config.RuntimeParams["replication"] = "database"
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to %s for replication: %w", ContainerPrettyName, err)
+	}
+
+	publication := "FOR ALL TABLES"
+	if tables != "" {
+		var names []string
+		for _, table := range strings.Split(tables, ",") {
+			names = append(names, pgx.Identifier(strings.Split(table, ".")).Sanitize())
+		}
+		publication = "FOR TABLE " + strings.Join(names, ", ")
+	}
+	suffix, err := mockestra.RandomPassword(4)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("failed to generate replication slot name: %w", err)
+	}
+	c := &ChangeCapture{
+		conn:      conn,
+		slot:      changePublication + "_" + suffix,
+		changes:   make(chan Change, changeBuffer),
+		relations: make(map[uint32]*pglogrepl.RelationMessage),
+		types:     pgtype.NewMap(),
+		done:      make(chan struct{}),
+	}
+	for _, statement := range []string{
+		"DROP PUBLICATION IF EXISTS " + changePublication,
+		fmt.Sprintf("CREATE PUBLICATION %s %s", changePublication, publication),
+	} {
+		if _, err := conn.Exec(ctx, statement).ReadAll(); err != nil {
+			conn.Close(ctx)
+			return nil, fmt.Errorf("failed to set up %s change capture: %w", ContainerPrettyName, err)
+		}
+	}
+	_, err = pglogrepl.CreateReplicationSlot(ctx, conn, c.slot, "pgoutput", pglogrepl.CreateReplicationSlotOptions{
+		Temporary: true,
+		Mode:      pglogrepl.LogicalReplication,
+	})
+	if err != nil {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("failed to set up %s change capture: %w", ContainerPrettyName, err)
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := c.start(ctx); err != nil {
+				return err
+			}
+			streamCtx, cancel := context.WithCancel(context.Background())
+			c.cancel = cancel
+			go c.stream(streamCtx)
+			slog.Info(fmt.Sprintf("%s change capture is running", ContainerPrettyName), "slot", c.slot)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if c.cancel != nil {
+				c.cancel()
+				<-c.done
+			}
+			return conn.Close(ctx)
+		},
+	})
+	return c, nil
+}
+
+// Changes returns the stream of captured changes, in commit order.
+func (c *ChangeCapture) Changes() <-chan Change {
+	return c.changes
+}
+
+// Err returns the error that stopped the stream, if any.
+func (c *ChangeCapture) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// WaitFor consumes changes from the stream until one satisfies match, and
+// returns it. Changes consumed along the way are discarded. It fails once ctx
+// is done, e.g. after a timeout set with context.WithTimeout.
+func (c *ChangeCapture) WaitFor(ctx context.Context, match func(Change) bool) (Change, error) {
+	for {
+		select {
+		case change, ok := <-c.changes:
+			if !ok {
+				if err := c.Err(); err != nil {
+					return Change{}, fmt.Errorf("%s change stream failed: %w", ContainerPrettyName, err)
+				}
+				return Change{}, fmt.Errorf("%s change stream is closed", ContainerPrettyName)
+			}
+			if match(change) {
+				return change, nil
+			}
+		case <-ctx.Done():
+			return Change{}, fmt.Errorf("no matching %s change: %w", ContainerPrettyName, ctx.Err())
+		}
+	}
+}
+
+// start switches the connection to streaming from the slot.
+func (c *ChangeCapture) start(ctx context.Context) error {
+	err := pglogrepl.StartReplication(ctx, c.conn, c.slot, 0, pglogrepl.StartReplicationOptions{
+		PluginArgs: []string{"proto_version '1'", fmt.Sprintf("publication_names '%s'", changePublication)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start %s replication: %w", ContainerPrettyName, err)
+	}
+	return nil
+}
+
+// stream decodes the replication stream into changes until ctx is done,
+// acknowledging the position received so far to the server.
+func (c *ChangeCapture) stream(ctx context.Context) {
+	defer close(c.done)
+	defer close(c.changes)
+	var (
+		position   pglogrepl.LSN
+		commitTime time.Time
+		deadline   = time.Now().Add(standbyStatusInterval)
+	)
+	for {
+		if time.Now().After(deadline) {
+			if err := pglogrepl.SendStandbyStatusUpdate(ctx, c.conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: position}); err != nil {
+				c.fail(ctx, err)
+				return
+			}
+			deadline = time.Now().Add(standbyStatusInterval)
+		}
+		receiveCtx, cancel := context.WithDeadline(ctx, deadline)
+		msg, err := c.conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && pgconn.Timeout(err) {
+				continue
+			}
+			c.fail(ctx, err)
+			return
+		}
+		data, ok := msg.(*pgproto3.CopyData)
+		if !ok {
+			if msg, ok := msg.(*pgproto3.ErrorResponse); ok {
+				c.fail(ctx, pgconn.ErrorResponseToPgError(msg))
+				return
+			}
+			continue
+		}
+		if len(data.Data) == 0 {
+			continue
+		}
+		switch data.Data[0] {
+		case pglogrepl.PrimaryKeepaliveMessageByteID:
+			keepalive, err := pglogrepl.ParsePrimaryKeepaliveMessage(data.Data[1:])
+			if err != nil {
+				c.fail(ctx, err)
+				return
+			}
+			if keepalive.ReplyRequested {
+				deadline = time.Time{}
+			}
+		case pglogrepl.XLogDataByteID:
+			xlog, err := pglogrepl.ParseXLogData(data.Data[1:])
+			if err != nil {
+				c.fail(ctx, err)
+				return
+			}
+			position = max(position, xlog.WALStart+pglogrepl.LSN(len(xlog.WALData)))
+			message, err := pglogrepl.Parse(xlog.WALData)
+			if err != nil {
+				c.fail(ctx, err)
+				return
+			}
+			if begin, ok := message.(*pglogrepl.BeginMessage); ok {
+				commitTime = begin.CommitTime
+				continue
+			}
+			change, ok, err := c.decode(message)
+			if err != nil {
+				c.fail(ctx, err)
+				return
+			}
+			if !ok {
+				continue
+			}
+			change.CommitTime = commitTime
+			select {
+			case c.changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (c *ChangeCapture) fail(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	slog.Warn(fmt.Sprintf("%s change capture stopped", ContainerPrettyName), "error", err)
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+}
+
+// decode turns a pgoutput message into a change, reporting whether it is a
+// row change. Relation messages describing tables are recorded for the row
+// changes that follow them.
+func (c *ChangeCapture) decode(message pglogrepl.Message) (Change, bool, error) {
+	var (
+		change     Change
+		relationID uint32
+		row, old   *pglogrepl.TupleData
+	)
+	switch message := message.(type) {
+	case *pglogrepl.RelationMessage:
+		c.relations[message.RelationID] = message
+		return Change{}, false, nil
+	case *pglogrepl.InsertMessage:
+		change.Operation, relationID, row = ChangeInsert, message.RelationID, message.Tuple
+	case *pglogrepl.UpdateMessage:
+		change.Operation, relationID, row, old = ChangeUpdate, message.RelationID, message.NewTuple, message.OldTuple
+	case *pglogrepl.DeleteMessage:
+		change.Operation, relationID, row = ChangeDelete, message.RelationID, message.OldTuple
+	default:
+		return Change{}, false, nil
+	}
+	rel, ok := c.relations[relationID]
+	if !ok {
+		return Change{}, false, fmt.Errorf("%s of unknown relation %d", change.Operation, relationID)
+	}
+	change.Schema, change.Table = rel.Namespace, rel.RelationName
+	change.Row = c.row(rel, row)
+	change.OldRow = c.row(rel, old)
+	return change, true, nil
+}
+
+// row decodes the text values of tuple into the Go types of pgx.
+func (c *ChangeCapture) row(rel *pglogrepl.RelationMessage, tuple *pglogrepl.TupleData) map[string]any {
+	if tuple == nil {
+		return nil
+	}
+	row := make(map[string]any)
+	for i, column := range tuple.Columns {
+		if i >= len(rel.Columns) {
+			break
+		}
+		name, oid := rel.Columns[i].Name, rel.Columns[i].DataType
+		switch column.DataType {
+		case pglogrepl.TupleDataTypeNull:
+			row[name] = nil
+		case pglogrepl.TupleDataTypeText:
+			row[name] = string(column.Data)
+			if t, ok := c.types.TypeForOID(oid); ok {
+				if decoded, err := t.Codec.DecodeValue(c.types, oid, pgtype.TextFormatCode, column.Data); err == nil {
+					row[name] = decoded
+				}
+			}
+		}
+	}
+	return row
+}
+
+// ChangeCaptureModule provides the *ChangeCapture named "postgres" of the container.
+var ChangeCaptureModule = fx.Provide(
+	fx.Annotate(
+		NewChangeCapture,
+		fx.ResultTags(`name:"postgres"`),
+	),
+)
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/narwhl/mockestra/pki"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// connectionString builds the DSN of the database configured on req, served at addr.
+func connectionString(req *testcontainers.GenericContainerRequest, addr string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s/%s?sslmode=disable",
+		req.Env["POSTGRES_USER"],
+		req.Env["POSTGRES_PASSWORD"],
+		addr,
+		req.Env["POSTGRES_DB"],
+	)
+}
+
+// adminUsername returns the admin user configured on req, or the image default.
+func adminUsername(req *testcontainers.GenericContainerRequest) string {
+	return cmp.Or(req.Env["POSTGRES_USER"], "postgres")
+}
+
+// defaultDatabase returns the database configured on req, or the image default.
+func defaultDatabase(req *testcontainers.GenericContainerRequest) string {
+	return cmp.Or(req.Env["POSTGRES_DB"], adminUsername(req))
+}
+
+// adminConnectionString builds the DSN of database on the server at addr,
+// authenticated as the admin user configured on req.
+func adminConnectionString(req *testcontainers.GenericContainerRequest, addr, database string) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(adminUsername(req), req.Env["POSTGRES_PASSWORD"]),
+		Host:     addr,
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}
+	return dsn.String()
+}
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+	CA        *pki.CA                                 `optional:"true"`
+}
+
+// NewClient returns a connection pool to the database configured on the container,
+// verifying the server against the stack CA when one is provided.
+// The pool is closed when the app stops.
+func NewClient(p ClientParams) (*pgxpool.Pool, error) {
+	addr, err := p.Container.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	config, err := pgxpool.ParseConfig(connectionString(p.Request, addr))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while parsing %s connection string: %w", ContainerPrettyName, err)
+	}
+	if p.CA != nil {
+		host, _, _ := net.SplitHostPort(addr)
+		config.ConnConfig.TLSConfig = &tls.Config{
+			RootCAs:    p.CA.CertPool(),
+			ServerName: host,
+		}
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			pool.Close()
+			return nil
+		},
+	})
+	return pool, nil
+}
+
+// ClientModule provides a *pgxpool.Pool named "postgres" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"postgres"`),
+	),
+)
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+type SQLDatabaseParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+}
+
+// NewSQLDatabase returns the server of the container as a mockestra.SQLDatabase,
+// administered with the credentials configured on the request or the image
+// defaults, along with the databases created by WithDatabases.
+func NewSQLDatabase(p SQLDatabaseParams) mockestra.SQLDatabase {
+	db := &mockestra.PostgresDatabase{
+		Container: p.Container,
+		Port:      Port,
+		Username:  adminUsername(p.Request),
+		Password:  p.Request.Env["POSTGRES_PASSWORD"],
+		Database:  defaultDatabase(p.Request),
+	}
+	for _, init := range initDatabases(p.Request) {
+		if init.Name == db.Database {
+			continue
+		}
+		db.ExtraDatabases = append(db.ExtraDatabases, mockestra.DatabaseLogin{
+			Database: init.Name,
+			Username: init.Owner,
+			Password: init.Password,
+		})
+	}
+	return db
+}
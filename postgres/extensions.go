@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// extensionVariants maps extensions missing from the plain image to the
+// image variant shipping them, formatted with the Postgres major version.
+var extensionVariants = map[string]string{
+	"vector":                 "pgvector/pgvector:pg%s",
+	"postgis":                "postgis/postgis:%s-3.5",
+	"postgis_raster":         "postgis/postgis:%s-3.5",
+	"postgis_topology":       "postgis/postgis:%s-3.5",
+	"postgis_tiger_geocoder": "postgis/postgis:%s-3.5",
+}
+
+// WithImage overrides the image of the container, e.g. an image bundling
+// several extensions. Apply it after WithExtensions to take precedence
+// over the variant picked there.
+func WithImage(image string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		req.Image = image
+		return nil
+	}
+}
+
+// WithExtensions creates extensions in every database of the server once it
+// is ready, ahead of the hooks of other options such as WithMigration.
+// Extensions are also created in template1, so databases created later on
+// have them as well. Extensions outside of the contrib modules of the plain
+// image, such as vector and postgis, switch the image to a variant shipping
+// them, unless the image was overridden with WithImage beforehand. Picking a
+// variant requires a version tag starting with the major version, as the
+// variants are published per major version.
+// An extension the image does not ship fails the container.
+func WithExtensions(extensions ...string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if err := pickImageVariant(req, extensions); err != nil {
+			return err
+		}
+		hooks := testcontainers.ContainerLifecycleHooks{
+			PostReadies: []testcontainers.ContainerHook{
+				func(ctx context.Context, container testcontainers.Container) error {
+					addr, err := container.PortEndpoint(ctx, Port, "")
+					if err != nil {
+						return fmt.Errorf("encounter error getting addr while creating extensions: %w", err)
+					}
+					return createExtensions(ctx, req, addr, extensions)
+				},
+			},
+		}
+		req.LifecycleHooks = append([]testcontainers.ContainerLifecycleHooks{hooks}, req.LifecycleHooks...)
+		return nil
+	}
+}
+
+// pickImageVariant switches the plain image of req to the variant shipping
+// extensions, keeping its Postgres major version.
+func pickImageVariant(req *testcontainers.GenericContainerRequest, extensions []string) error {
+	repository, version, _ := strings.Cut(req.Image, ":")
+	if repository != Image {
+		return nil
+	}
+	var variant string
+	for _, extension := range extensions {
+		image, ok := extensionVariants[extension]
+		if !ok || image == variant {
+			continue
+		}
+		if variant != "" {
+			return fmt.Errorf("no %s image variant ships extensions %s, set an image bundling them with WithImage", ContainerPrettyName, strings.Join(extensions, ", "))
+		}
+		variant = image
+	}
+	if variant == "" {
+		return nil
+	}
+	major, _, _ := strings.Cut(version, ".")
+	major, _, _ = strings.Cut(major, "-")
+	if major == "" || strings.Trim(major, "0123456789") != "" {
+		return fmt.Errorf("cannot tell the %s major version of image %s to pick a variant shipping extensions %s, pin a version such as 17 or set an image with WithImage", ContainerPrettyName, req.Image, strings.Join(extensions, ", "))
+	}
+	req.Image = fmt.Sprintf(variant, major)
+	return nil
+}
+
+// createExtensions creates extensions in template1 and every database
+// accepting connections, after checking that the image ships them.
+func createExtensions(ctx context.Context, req *testcontainers.GenericContainerRequest, addr string, extensions []string) error {
+	conn, err := pgx.Connect(ctx, adminConnectionString(req, addr, defaultDatabase(req)))
+	if err != nil {
+		return fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	rows, err := conn.Query(ctx, "SELECT name FROM pg_catalog.pg_available_extensions")
+	if err != nil {
+		conn.Close(ctx)
+		return fmt.Errorf("failed to list available extensions: %w", err)
+	}
+	available, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		conn.Close(ctx)
+		return fmt.Errorf("failed to list available extensions: %w", err)
+	}
+	for _, extension := range extensions {
+		if !slices.Contains(available, extension) {
+			conn.Close(ctx)
+			return fmt.Errorf("extension %s is not available in image %s, set an image shipping it with WithImage", extension, req.Image)
+		}
+	}
+	rows, err = conn.Query(ctx, "SELECT datname FROM pg_catalog.pg_database WHERE datallowconn AND (NOT datistemplate OR datname = 'template1')")
+	if err != nil {
+		conn.Close(ctx)
+		return fmt.Errorf("failed to list databases: %w", err)
+	}
+	databases, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	conn.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	for _, database := range databases {
+		conn, err := pgx.Connect(ctx, adminConnectionString(req, addr, database))
+		if err != nil {
+			return fmt.Errorf("an error occurred while connecting to %s database %s: %w", ContainerPrettyName, database, err)
+		}
+		for _, extension := range extensions {
+			if _, err := conn.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+pgx.Identifier{extension}.Sanitize()); err != nil {
+				conn.Close(ctx)
+				return fmt.Errorf("failed to create extension %s in database %s: %w", extension, database, err)
+			}
+		}
+		conn.Close(ctx)
+	}
+	slog.Info("Postgres extensions created", "extensions", extensions, "databases", databases)
+	return nil
+}
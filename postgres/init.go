@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// initScriptsDir is where the image entrypoint picks up scripts run on first start.
+const initScriptsDir = "/docker-entrypoint-initdb.d"
+
+// Database describes a database created by the init scripts on first start.
+type Database struct {
+	Name string
+	// Owner is the login role owning the database and its schemas,
+	// created unless it exists. Empty leaves them to the admin user.
+	Owner string
+	// Password of Owner when the role is created. Empty creates it without one.
+	Password string
+	// Schemas are created in the database unless they exist.
+	Schemas []string
+	// Extensions are created in the database unless they exist.
+	Extensions []string
+}
+
+// script renders the psql script creating the database. Names are quoted
+// as identifiers and values as literals, leaving format to quote the
+// statements that psql builds through \gexec.
+func (d Database) script() (string, error) {
+	if d.Name == "" {
+		return "", errors.New("database name is required")
+	}
+	if d.Owner == "" && d.Password != "" {
+		return "", fmt.Errorf("database %s has a password but no owner", d.Name)
+	}
+	var b strings.Builder
+	name := pgx.Identifier{d.Name}.Sanitize()
+	if d.Owner != "" {
+		owner := pgx.Identifier{d.Owner}.Sanitize()
+		createRole := "CREATE ROLE %I WITH LOGIN"
+		args := mockestra.QuoteLiteral(d.Owner)
+		if d.Password != "" {
+			createRole += " PASSWORD %L"
+			args += ", " + mockestra.QuoteLiteral(d.Password)
+		}
+		fmt.Fprintf(&b, "SELECT format(%s, %s)\nWHERE NOT EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = %s)\\gexec\n\n",
+			mockestra.QuoteLiteral(createRole), args, mockestra.QuoteLiteral(d.Owner))
+		fmt.Fprintf(&b, "SELECT format('CREATE DATABASE %%I OWNER %%I', %s, %s)\nWHERE NOT EXISTS (SELECT FROM pg_catalog.pg_database WHERE datname = %s)\\gexec\n\n",
+			mockestra.QuoteLiteral(d.Name), mockestra.QuoteLiteral(d.Owner), mockestra.QuoteLiteral(d.Name))
+		fmt.Fprintf(&b, "GRANT ALL PRIVILEGES ON DATABASE %s TO %s;\n", name, owner)
+	} else {
+		fmt.Fprintf(&b, "SELECT format('CREATE DATABASE %%I', %s)\nWHERE NOT EXISTS (SELECT FROM pg_catalog.pg_database WHERE datname = %s)\\gexec\n",
+			mockestra.QuoteLiteral(d.Name), mockestra.QuoteLiteral(d.Name))
+	}
+
+	if len(d.Schemas) == 0 && len(d.Extensions) == 0 {
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "\n\\connect %s\n", name)
+	for _, schema := range d.Schemas {
+		fmt.Fprintf(&b, "CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{schema}.Sanitize())
+		if d.Owner != "" {
+			fmt.Fprintf(&b, " AUTHORIZATION %s", pgx.Identifier{d.Owner}.Sanitize())
+		}
+		b.WriteString(";\n")
+	}
+	for _, extension := range d.Extensions {
+		fmt.Fprintf(&b, "CREATE EXTENSION IF NOT EXISTS %s;\n", pgx.Identifier{extension}.Sanitize())
+	}
+	return b.String(), nil
+}
+
+// WithInitScript adds an SQL script run by the image entrypoint on first
+// start, after the init scripts added before it.
+func WithInitScript(script string) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		req.Files = append(req.Files, testcontainers.ContainerFile{
+			Reader:            strings.NewReader(script),
+			ContainerFilePath: initScriptPath(req, "sql"),
+			FileMode:          0o644,
+		})
+		return nil
+	}
+}
+
+// initScriptPath returns the path of the next init script of req with
+// extension ext, ordered after the init scripts added before it.
+func initScriptPath(req *testcontainers.GenericContainerRequest, ext string) string {
+	var count int
+	for _, f := range req.Files {
+		if path.Dir(f.ContainerFilePath) == initScriptsDir {
+			count++
+		}
+	}
+	return fmt.Sprintf("%s/%03d-mockestra.%s", initScriptsDir, count, ext)
+}
+
+// initScript reads the init script WithDatabases adds for database, which
+// is kept along so that the databases of a request and the roles owning them
+// can be recovered without exposing passwords in labels.
+type initScript struct {
+	*strings.Reader
+	database Database
+}
+
+// WithDatabases creates every database on first start, along with its
+// owner, schemas and extensions.
+func WithDatabases(databases ...Database) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		for _, db := range databases {
+			script, err := db.script()
+			if err != nil {
+				return fmt.Errorf("invalid %s init database: %w", ContainerPrettyName, err)
+			}
+			req.Files = append(req.Files, testcontainers.ContainerFile{
+				Reader:            initScript{Reader: strings.NewReader(script), database: db},
+				ContainerFilePath: initScriptPath(req, "sql"),
+				FileMode:          0o644,
+			})
+		}
+		return nil
+	}
+}
+
+// initDatabases returns the databases added to req by WithDatabases in order.
+func initDatabases(req *testcontainers.GenericContainerRequest) []Database {
+	var databases []Database
+	for _, f := range req.Files {
+		if script, ok := f.Reader.(initScript); ok {
+			databases = append(databases, script.database)
+		}
+	}
+	return databases
+}
+
+// Databases returns the database configured on req, followed by the ones
+// created on first start by WithDatabases.
+func Databases(req *testcontainers.GenericContainerRequest) []string {
+	databases := []string{defaultDatabase(req)}
+	for _, db := range initDatabases(req) {
+		if !slices.Contains(databases, db.Name) {
+			databases = append(databases, db.Name)
+		}
+	}
+	return databases
+}
+
+// WithExtraDatabase creates databaseName owned by the login role username
+// with password on first start.
+func WithExtraDatabase(databaseName, username, password string) testcontainers.CustomizeRequestOption {
+	return WithDatabases(Database{
+		Name:     databaseName,
+		Owner:    username,
+		Password: password,
+	})
+}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// maintenanceDatabase is where Isolation connects to manage databases,
+// since a template cannot be copied while connections to it are open.
+// fallbackMaintenanceDatabase is used when the migrated database is the former.
+const (
+	maintenanceDatabase         = "postgres"
+	fallbackMaintenanceDatabase = "template1"
+)
+
+type IsolationParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+}
+
+// Isolation hands out fresh copies of the database configured on the
+// container, so that migrations run once through WithMigration and every
+// test starts from the migrated state. Once the PostReady hooks of every
+// option ran, and before dependents connect, the database is copied into a
+// template that accepts no connections, and every clone is created from that
+// template with CREATE DATABASE … TEMPLATE.
+type Isolation struct {
+	request   *testcontainers.GenericContainerRequest
+	container testcontainers.Container
+
+	// mu serializes template copies, as Postgres refuses to copy a
+	// template that another CREATE DATABASE is reading.
+	mu     sync.Mutex
+	clones int
+}
+
+// NewIsolation returns the Isolation of the container.
+func NewIsolation(p IsolationParams) *Isolation {
+	return &Isolation{
+		request:   p.Request,
+		container: p.Container,
+	}
+}
+
+// Clone creates a fresh copy of the migrated database and returns its DSN,
+// reachable from the host, along with a func dropping it. Clone is safe to
+// call from parallel tests.
+func (i *Isolation) Clone(ctx context.Context) (string, func(context.Context) error, error) {
+	addr, err := i.container.PortEndpoint(ctx, Port, "")
+	if err != nil {
+		return "", nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	conn, err := pgx.Connect(ctx, i.dsn(addr, i.maintenance()))
+	if err != nil {
+		return "", nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	defer conn.Close(ctx)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	template := templateDatabase(i.request)
+	suffix, err := mockestra.RandomPassword(4)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate clone name: %w", err)
+	}
+	i.clones++
+	clone := fmt.Sprintf("isolated_%d_%s", i.clones, suffix)
+	if _, err := conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", pgx.Identifier{clone}.Sanitize(), pgx.Identifier{template}.Sanitize())); err != nil {
+		return "", nil, fmt.Errorf("failed to clone database %s: %w", template, err)
+	}
+
+	drop := func(ctx context.Context) error {
+		conn, err := pgx.Connect(ctx, i.dsn(addr, i.maintenance()))
+		if err != nil {
+			return fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+		}
+		defer conn.Close(ctx)
+		if _, err := conn.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{clone}.Sanitize())); err != nil {
+			return fmt.Errorf("failed to drop database %s: %w", clone, err)
+		}
+		return nil
+	}
+	return i.dsn(addr, clone), drop, nil
+}
+
+// Database returns the DSN of a fresh clone for tb, dropped at tb.Cleanup.
+func (i *Isolation) Database(tb testing.TB) string {
+	tb.Helper()
+	dsn, drop, err := i.Clone(tb.Context())
+	if err != nil {
+		tb.Fatalf("failed to clone %s database: %v", ContainerPrettyName, err)
+	}
+	tb.Cleanup(func() {
+		if err := drop(context.Background()); err != nil {
+			tb.Errorf("failed to drop %s database clone: %v", ContainerPrettyName, err)
+		}
+	})
+	return dsn
+}
+
+// isolationTemplate is the option IsolationModule adds to the module, which
+// New applies after every other option so that the template is copied once
+// migrations and seeds ran.
+type isolationTemplate struct{}
+
+// Customize copies the database configured on req into its template in the
+// PostReady phase, before Actualize hands the container to dependents and
+// client modules, so that no connection has to be terminated for the copy.
+func (isolationTemplate) Customize(req *testcontainers.GenericContainerRequest) error {
+	req.LifecycleHooks = append(req.LifecycleHooks, testcontainers.ContainerLifecycleHooks{
+		PostReadies: []testcontainers.ContainerHook{
+			func(ctx context.Context, container testcontainers.Container) error {
+				addr, err := container.PortEndpoint(ctx, Port, "")
+				if err != nil {
+					return fmt.Errorf("encounter error getting addr while creating template: %w", err)
+				}
+				return createTemplate(ctx, adminConnectionString(req, addr, maintenanceOf(req)), req)
+			},
+		},
+	})
+	return nil
+}
+
+// templateDatabase names the template of the database configured on req.
+func templateDatabase(req *testcontainers.GenericContainerRequest) string {
+	return defaultDatabase(req) + "_template"
+}
+
+// createTemplate copies the migrated database of req into its template,
+// replacing the one left by an earlier run of a reused container.
+func createTemplate(ctx context.Context, dsn string, req *testcontainers.GenericContainerRequest) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	defer conn.Close(ctx)
+
+	source := defaultDatabase(req)
+	template := templateDatabase(req)
+	// a template left by an earlier run of a reused container has to be
+	// unmarked before it can be dropped
+	var stale bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT FROM pg_catalog.pg_database WHERE datname = $1)", template).Scan(&stale); err != nil {
+		return fmt.Errorf("failed to look up template %s: %w", template, err)
+	}
+	if stale {
+		for _, statement := range []string{
+			"ALTER DATABASE %s WITH IS_TEMPLATE false",
+			"DROP DATABASE %s WITH (FORCE)",
+		} {
+			if _, err := conn.Exec(ctx, fmt.Sprintf(statement, pgx.Identifier{template}.Sanitize())); err != nil {
+				return fmt.Errorf("failed to drop stale template %s: %w", template, err)
+			}
+		}
+	}
+	if _, err := conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", pgx.Identifier{template}.Sanitize(), pgx.Identifier{source}.Sanitize())); err != nil {
+		return fmt.Errorf("failed to create template from database %s: %w", source, err)
+	}
+	if _, err := conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s WITH IS_TEMPLATE true ALLOW_CONNECTIONS false", pgx.Identifier{template}.Sanitize())); err != nil {
+		return fmt.Errorf("failed to mark database %s as template: %w", template, err)
+	}
+	return nil
+}
+
+func (i *Isolation) maintenance() string {
+	return maintenanceOf(i.request)
+}
+
+// maintenanceOf returns the database to manage the databases of req from.
+func maintenanceOf(req *testcontainers.GenericContainerRequest) string {
+	if defaultDatabase(req) == maintenanceDatabase {
+		return fallbackMaintenanceDatabase
+	}
+	return maintenanceDatabase
+}
+
+func (i *Isolation) dsn(addr, database string) string {
+	return adminConnectionString(i.request, addr, database)
+}
+
+// IsolationModule provides an *Isolation named "postgres" for the container,
+// and has the module copy the migrated database into the template clones are
+// created from.
+var IsolationModule = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			NewIsolation,
+			fx.ResultTags(`name:"postgres"`),
+		),
+	),
+	fx.Supply(
+		fx.Annotate(
+			isolationTemplate{},
+			fx.As(new(testcontainers.ContainerCustomizer)),
+			fx.ResultTags(`group:"postgres"`),
+		),
+	),
+)
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// WithMigrationsFS applies the `{version}_{title}.up.sql` files in dir of
+// fsys to the database configured on the container once it is ready,
+// recording them in the mockestra.MigrationsTable table.
+func WithMigrationsFS(fsys fs.FS, dir string) testcontainers.CustomizeRequestOption {
+	return WithConnection("running migrations", func(ctx context.Context, dsn string) error {
+		return mockestra.MigrateFS(ctx, dsn, fsys, dir)
+	})
+}
+
+// WithSeedFS loads the SQL and CSV files of fsys into the database configured
+// on the container once it is ready, after the migrations of options applied
+// before it. See mockestra.SeedFS for how files map to tables.
+func WithSeedFS(fsys fs.FS) testcontainers.CustomizeRequestOption {
+	return WithConnection("seeding", func(ctx context.Context, dsn string) error {
+		return mockestra.SeedFS(ctx, dsn, fsys)
+	})
+}
+
+// WithConnection runs fn in the PostReady phase with the DSN of the database
+// configured on the container, which Postgres compatible modules such as
+// timescaledb share. action describes fn in errors.
+func WithConnection(action string, fn func(ctx context.Context, dsn string) error) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		req.LifecycleHooks = append(req.LifecycleHooks, testcontainers.ContainerLifecycleHooks{
+			PostReadies: []testcontainers.ContainerHook{
+				func(ctx context.Context, container testcontainers.Container) error {
+					addr, err := container.PortEndpoint(ctx, Port, "")
+					if err != nil {
+						return fmt.Errorf("encounter error getting addr while %s: %w", action, err)
+					}
+					return fn(ctx, connectionString(req, addr))
+				},
+			},
+		})
+		return nil
+	}
+}
@@ -1,12 +1,15 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"slices"
+	"strings"
 
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"go.uber.org/fx"
@@ -18,6 +21,22 @@ const (
 	Port  = "5432/tcp"
 
 	ContainerPrettyName = "Postgres"
+
+	// Container paths for TLS material issued by the stack CA
+	containerTLSDir        = "/tmp/mockestra/postgres"
+	containerCAPath        = containerTLSDir + "/ca.pem"
+	containerCertPath      = containerTLSDir + "/server.crt"
+	containerKeyPath       = containerTLSDir + "/server.key"
+	containerEntrypointTLS = containerTLSDir + "/docker-entrypoint-tls.sh"
+
+	// tlsEntrypoint hands the key material over to the postgres user,
+	// since files copied into the container are owned by root and
+	// postgres refuses to load a key it cannot exclusively read.
+	tlsEntrypoint = `#!/bin/sh
+set -e
+chown postgres:postgres ` + containerCAPath + " " + containerCertPath + " " + containerKeyPath + `
+exec /usr/local/bin/docker-entrypoint.sh "$@"
+`
 )
 
 var (
@@ -37,15 +56,7 @@ func WithMigration(fn migration) testcontainers.CustomizeRequestOption {
 					if err != nil {
 						return fmt.Errorf("encounter error getting addr while running migration: %w", err)
 					}
-					return fn(
-						fmt.Sprintf(
-							"postgres://%s:%s@%s/%s?sslmode=disable",
-							req.Env["POSTGRES_USER"],
-							req.Env["POSTGRES_PASSWORD"],
-							addr,
-							req.Env["POSTGRES_DB"],
-						),
-					)
+					return fn(connectionString(req, addr))
 				},
 			},
 		})
@@ -53,35 +64,51 @@ func WithMigration(fn migration) testcontainers.CustomizeRequestOption {
 	}
 }
 
-func WithExtraDatabase(databaseName, username, password string) testcontainers.CustomizeRequestOption {
-	initScript := fmt.Sprintf(`
--- Create user if not exists
-DO $$
-BEGIN
-  IF NOT EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = '%[2]s') THEN
-    CREATE USER %[2]s WITH PASSWORD '%[3]s';
-  END IF;
-END
-$$;
-
--- Create database using conditional logic
-SELECT 'CREATE DATABASE %[1]s WITH OWNER %[2]s'
-WHERE NOT EXISTS (SELECT FROM pg_catalog.pg_database WHERE datname = '%[1]s')\gexec
-
--- Grant privileges
-GRANT ALL PRIVILEGES ON DATABASE %[1]s TO %[2]s;
-`, databaseName, username, password)
-	tempInitFile, err := os.CreateTemp("", fmt.Sprintf("%s-db-init.*.sql", databaseName))
-	if err != nil {
-		slog.Error("failed to create temp init file", "err", err)
-		return nil
-	}
-	defer tempInitFile.Close()
-	if _, err := tempInitFile.Write([]byte(initScript)); err != nil {
-		slog.Error("failed to write to temp init file", "err", err)
+// WithCA enables TLS on the server with a leaf certificate issued by ca
+// for the container name, network aliases, localhost and 127.0.0.1.
+// Plain connections are still accepted, so dependents using sslmode=disable
+// keep working.
+func WithCA(ca *pki.CA) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		serverCert, err := ca.IssueServer(Tag, pki.ServerNames(req.Name, req.NetworkAliases)...)
+		if err != nil {
+			return fmt.Errorf("failed to issue %s server certificate: %w", ContainerPrettyName, err)
+		}
+		req.Files = append(req.Files,
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(ca.CertPEM),
+				ContainerFilePath: containerCAPath,
+				FileMode:          0o600,
+			},
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.CertPEM),
+				ContainerFilePath: containerCertPath,
+				FileMode:          0o600,
+			},
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.KeyPEM),
+				ContainerFilePath: containerKeyPath,
+				FileMode:          0o600,
+			},
+			testcontainers.ContainerFile{
+				Reader:            strings.NewReader(tlsEntrypoint),
+				ContainerFilePath: containerEntrypointTLS,
+				FileMode:          0o755,
+			},
+		)
+		req.Entrypoint = []string{"sh", containerEntrypointTLS}
+		// overriding the entrypoint discards the image CMD
+		if len(req.Cmd) == 0 {
+			req.Cmd = []string{"postgres"}
+		}
+		req.Cmd = append(req.Cmd,
+			"-c", "ssl=on",
+			"-c", "ssl_ca_file="+containerCAPath,
+			"-c", "ssl_cert_file="+containerCertPath,
+			"-c", "ssl_key_file="+containerKeyPath,
+		)
 		return nil
 	}
-	return postgres.WithInitScripts(tempInitFile.Name())
 }
 
 type RequestParams struct {
@@ -89,6 +116,7 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"postgres_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"postgres"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 // New is a constructor that returns a testcontainers.GenericContainerRequest
@@ -106,12 +134,26 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		Started: true,
 	}
 
-	for _, opt := range append(p.Opts, postgres.BasicWaitStrategies()) {
+	// the isolation template is copied last, over the migrated database
+	opts := slices.DeleteFunc(slices.Clone(p.Opts), func(opt testcontainers.ContainerCustomizer) bool {
+		_, ok := opt.(isolationTemplate)
+		return ok
+	})
+	if len(opts) < len(p.Opts) {
+		opts = append(opts, isolationTemplate{})
+	}
+	for _, opt := range append(opts, postgres.BasicWaitStrategies()) {
 		if err := opt.Customize(&r); err != nil {
 			return nil, err
 		}
 	}
 
+	if p.CA != nil {
+		if err := WithCA(p.CA).Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
@@ -171,5 +213,10 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"postgres"`),
 		),
 		Actualize,
+		ActualizeReplicas,
+		fx.Annotate(
+			NewSQLDatabase,
+			fx.ResultTags(`name:"postgres"`),
+		),
 	),
 )
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/fx"
+)
+
+const (
+	// replicasLabel carries the number of standbys set by WithReplicas.
+	replicasLabel = "mockestra.postgres.replicas"
+
+	// replicationHBA lets standbys on other containers stream from the
+	// primary, which only trusts local replication connections by default.
+	// The script is sourced by the image entrypoint after pg_hba.conf is set up.
+	replicationHBA = `echo "host replication all all ${POSTGRES_HOST_AUTH_METHOD:-scram-sha-256}" >> "$PGDATA/pg_hba.conf"
+`
+
+	// standbyEntrypoint clones the primary into an empty data directory with
+	// pg_basebackup, which also writes the standby configuration, before
+	// handing over to the image entrypoint that skips initialization on an
+	// existing data directory.
+	standbyEntrypoint = `set -e
+if [ ! -s "$PGDATA/PG_VERSION" ]; then
+	mkdir -p "$PGDATA"
+	chmod 700 "$PGDATA"
+	until pg_basebackup -h "$MOCKESTRA_PRIMARY_HOST" -p "$MOCKESTRA_PRIMARY_PORT" -U "$POSTGRES_USER" -D "$PGDATA" -R -X stream; do
+		sleep 1
+	done
+fi
+exec docker-entrypoint.sh postgres
+`
+)
+
+// WithReplicas starts n hot standbys streaming from the container, provided
+// as *Replica values of the `group:"postgres_replicas"` value group. They are
+// only started when the group is consumed.
+func WithReplicas(n int) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if n < 0 {
+			return fmt.Errorf("invalid number of %s replicas: %d", ContainerPrettyName, n)
+		}
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		if _, ok := req.Labels[replicasLabel]; !ok {
+			req.Files = append(req.Files, testcontainers.ContainerFile{
+				Reader:            strings.NewReader(replicationHBA),
+				ContainerFilePath: initScriptPath(req, "sh"),
+				FileMode:          0o644,
+			})
+		}
+		req.Labels[replicasLabel] = strconv.Itoa(n)
+		return nil
+	}
+}
+
+// Replica is a hot standby of the container started through WithReplicas.
+type Replica struct {
+	Container testcontainers.Container
+	request   *testcontainers.GenericContainerRequest
+}
+
+// DSN returns the DSN of the database configured on the primary, served
+// read-only by the replica and reachable from the host.
+func (r *Replica) DSN(ctx context.Context) (string, error) {
+	addr, err := r.Container.PortEndpoint(ctx, Port, "")
+	if err != nil {
+		return "", fmt.Errorf("an error occurred while querying %s replica endpoint: %w", ContainerPrettyName, err)
+	}
+	return adminConnectionString(r.request, addr, defaultDatabase(r.request)), nil
+}
+
+// PauseReplication stops the replica from replaying the changes it streams
+// from the primary, so that reads lag behind writes until ResumeReplication.
+func (r *Replica) PauseReplication(ctx context.Context) error {
+	return r.exec(ctx, "SELECT pg_catalog.pg_wal_replay_pause()")
+}
+
+// ResumeReplication catches the replica up with the primary after PauseReplication.
+func (r *Replica) ResumeReplication(ctx context.Context) error {
+	return r.exec(ctx, "SELECT pg_catalog.pg_wal_replay_resume()")
+}
+
+func (r *Replica) exec(ctx context.Context, statement string) error {
+	dsn, err := r.DSN(ctx)
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("an error occurred while connecting to %s replica: %w", ContainerPrettyName, err)
+	}
+	defer conn.Close(ctx)
+	if _, err := conn.Exec(ctx, statement); err != nil {
+		return fmt.Errorf("failed to run %s on %s replica: %w", statement, ContainerPrettyName, err)
+	}
+	return nil
+}
+
+type ReplicasParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+}
+
+type ReplicasResult struct {
+	fx.Out
+	Replicas        []*Replica                 `group:"postgres_replicas,flatten"`
+	ContainerGroups []testcontainers.Container `group:"containers,flatten"`
+}
+
+// ActualizeReplicas starts the standbys set by WithReplicas once the primary
+// is running, streaming from it over the address other containers reach it at.
+func ActualizeReplicas(p ReplicasParams) (ReplicasResult, error) {
+	n, _ := strconv.Atoi(p.Request.Labels[replicasLabel])
+	if n == 0 {
+		return ReplicasResult{}, nil
+	}
+	ctx := context.Background()
+	primary, err := mockestra.InternalEndpoint(ctx, p.Container, Port)
+	if err != nil {
+		return ReplicasResult{}, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	host, port, err := net.SplitHostPort(primary)
+	if err != nil {
+		return ReplicasResult{}, fmt.Errorf("invalid %s container endpoint %s: %w", ContainerPrettyName, primary, err)
+	}
+
+	var result ReplicasResult
+	for i := range n {
+		req := testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Name:         fmt.Sprintf("%s-replica-%d", p.Request.Name, i),
+				Image:        p.Request.Image,
+				User:         "postgres",
+				Entrypoint:   []string{"sh", "-c", standbyEntrypoint},
+				ExposedPorts: []string{Port},
+				Env: map[string]string{
+					"POSTGRES_USER":          adminUsername(p.Request),
+					"POSTGRES_PASSWORD":      p.Request.Env["POSTGRES_PASSWORD"],
+					"POSTGRES_DB":            defaultDatabase(p.Request),
+					"PGPASSWORD":             p.Request.Env["POSTGRES_PASSWORD"],
+					"MOCKESTRA_PRIMARY_HOST": host,
+					"MOCKESTRA_PRIMARY_PORT": port,
+				},
+				Labels:   mockestra.Labels(p.Request.Labels[mockestra.LabelPrefix], Tag),
+				Networks: p.Request.Networks,
+				WaitingFor: wait.ForAll(
+					wait.ForLog("database system is ready to accept read-only connections"),
+					wait.ForListeningPort(Port),
+				).WithDeadline(60 * time.Second),
+			},
+			Started: true,
+		}
+		c, err := testcontainers.GenericContainer(ctx, req)
+		if err != nil {
+			for _, replica := range result.Replicas {
+				replica.Container.Terminate(ctx)
+			}
+			return ReplicasResult{}, fmt.Errorf("an error occurred while instantiating %s replica %d: %w", ContainerPrettyName, i, err)
+		}
+		result.Replicas = append(result.Replicas, &Replica{Container: c, request: p.Request})
+		result.ContainerGroups = append(result.ContainerGroups, c)
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			for i, c := range result.ContainerGroups {
+				replicaPort, err := c.MappedPort(ctx, Port)
+				if err != nil {
+					return fmt.Errorf("an error occurred while querying %s replica mapped port: %w", ContainerPrettyName, err)
+				}
+				slog.Info(fmt.Sprintf("%s replica %d is running", ContainerPrettyName, i), "addr", fmt.Sprintf("localhost:%s", replicaPort.Port()))
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			var errs []error
+			for _, c := range result.ContainerGroups {
+				if err := c.Terminate(ctx); err != nil {
+					slog.Warn(fmt.Sprintf("an error occurred while terminating %s replica", ContainerPrettyName), "error", err)
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) == 0 {
+				slog.Info(fmt.Sprintf("%s replicas are terminated", ContainerPrettyName))
+			}
+			return errors.Join(errs...)
+		},
+	})
+	return result, nil
+}
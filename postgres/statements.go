@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+const (
+	// statementLogPrefix is the log_line_prefix set by WithStatementLog,
+	// telling apart the database and user of every server log line.
+	statementLogPrefix = "mockestra|%d|%u|"
+
+	// syncMarker is the statement StatementLog.Sync runs to find out that
+	// the log caught up, followed by a sequence number.
+	syncMarker = "SELECT 'mockestra:statement_log:sync:"
+)
+
+// statementLine matches the duration lines of statements run through the
+// simple protocol as well as executions of the extended protocol.
+var statementLine = regexp.MustCompile(`^mockestra\|([^|]*)\|([^|]*)\|LOG:  duration: ([0-9.]+) ms  (?:statement|execute [^:]*): (.*)$`)
+
+// LoggedStatement is a statement executed by the server, as captured by
+// WithStatementLog.
+type LoggedStatement struct {
+	Database  string
+	User      string
+	Duration  time.Duration
+	Statement string
+}
+
+// StatementLog collects the statements executed by the server from its log
+// stream. It is set up by WithStatementLog and provided by StatementLogModule.
+type StatementLog struct {
+	request   *testcontainers.GenericContainerRequest
+	container testcontainers.Container
+
+	mu         sync.Mutex
+	partial    []byte
+	continuing bool
+	statements []LoggedStatement
+	syncs      int
+	pending    map[string]chan struct{}
+}
+
+// WithStatementLog logs the duration of every statement executed by the
+// server, along with its database and user, and collects them into the
+// StatementLog of the container. Statements of the init scripts are
+// collected as well, until the log is reset.
+func WithStatementLog() testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if statementLogOf(req) != nil {
+			return nil
+		}
+		if len(req.Cmd) == 0 {
+			req.Cmd = []string{"postgres"}
+		}
+		req.Cmd = append(req.Cmd,
+			"-c", "log_min_duration_statement=0",
+			"-c", "log_line_prefix="+statementLogPrefix,
+		)
+		if req.LogConsumerCfg == nil {
+			req.LogConsumerCfg = &testcontainers.LogConsumerConfig{}
+		}
+		req.LogConsumerCfg.Consumers = append(req.LogConsumerCfg.Consumers, &StatementLog{
+			request: req,
+			pending: make(map[string]chan struct{}),
+		})
+		return nil
+	}
+}
+
+// statementLogOf returns the StatementLog consuming the logs of req, if any.
+func statementLogOf(req *testcontainers.GenericContainerRequest) *StatementLog {
+	if req.LogConsumerCfg == nil {
+		return nil
+	}
+	for _, consumer := range req.LogConsumerCfg.Consumers {
+		if log, ok := consumer.(*StatementLog); ok {
+			return log
+		}
+	}
+	return nil
+}
+
+// Accept implements testcontainers.LogConsumer, parsing the server log
+// stream line by line.
+func (l *StatementLog) Accept(log testcontainers.Log) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partial = append(l.partial, log.Content...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			return
+		}
+		l.parse(strings.TrimSuffix(string(l.partial[:i]), "\r"))
+		l.partial = l.partial[i+1:]
+	}
+}
+
+func (l *StatementLog) parse(line string) {
+	if !strings.HasPrefix(line, "mockestra|") {
+		// statements spanning several lines continue without the prefix
+		if l.continuing {
+			last := &l.statements[len(l.statements)-1]
+			last.Statement += "\n" + line
+		}
+		return
+	}
+	l.continuing = false
+	match := statementLine.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	if strings.HasPrefix(match[4], syncMarker) {
+		if done, ok := l.pending[match[4]]; ok {
+			close(done)
+			delete(l.pending, match[4])
+		}
+		return
+	}
+	ms, _ := strconv.ParseFloat(match[3], 64)
+	l.statements = append(l.statements, LoggedStatement{
+		Database:  match[1],
+		User:      match[2],
+		Duration:  time.Duration(ms * float64(time.Millisecond)),
+		Statement: match[4],
+	})
+	l.continuing = true
+}
+
+// Sync waits until the statements executed so far show up in the log, by
+// running a marker statement and waiting for it to be logged.
+func (l *StatementLog) Sync(ctx context.Context) error {
+	if l.container == nil {
+		return errors.New("statement log is not attached to a running container")
+	}
+	l.mu.Lock()
+	l.syncs++
+	marker := fmt.Sprintf("%s%d'", syncMarker, l.syncs)
+	done := make(chan struct{})
+	l.pending[marker] = done
+	l.mu.Unlock()
+
+	addr, err := l.container.PortEndpoint(ctx, Port, "")
+	if err != nil {
+		return fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	conn, err := pgx.Connect(ctx, adminConnectionString(l.request, addr, defaultDatabase(l.request)))
+	if err != nil {
+		return fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	defer conn.Close(ctx)
+	if _, err := conn.Exec(ctx, marker); err != nil {
+		return fmt.Errorf("failed to run statement log marker: %w", err)
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		delete(l.pending, marker)
+		l.mu.Unlock()
+		return fmt.Errorf("statement log did not catch up: %w", ctx.Err())
+	}
+}
+
+// Statements returns the statements executed since the log was last reset,
+// in order, once the log caught up with them.
+func (l *StatementLog) Statements(ctx context.Context) ([]LoggedStatement, error) {
+	if err := l.Sync(ctx); err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]LoggedStatement(nil), l.statements...), nil
+}
+
+// Matching returns the statements containing substr, e.g. a table name,
+// in the same way as Statements.
+func (l *StatementLog) Matching(ctx context.Context, substr string) ([]LoggedStatement, error) {
+	statements, err := l.Statements(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matching []LoggedStatement
+	for _, s := range statements {
+		if strings.Contains(s.Statement, substr) {
+			matching = append(matching, s)
+		}
+	}
+	return matching, nil
+}
+
+// Reset discards the statements executed so far, once the log caught up with them.
+func (l *StatementLog) Reset(ctx context.Context) error {
+	if err := l.Sync(ctx); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements = nil
+	l.continuing = false
+	return nil
+}
+
+type StatementLogParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"postgres"`
+	Container testcontainers.Container                `name:"postgres"`
+}
+
+// NewStatementLog returns the StatementLog set up on the container by WithStatementLog.
+func NewStatementLog(p StatementLogParams) (*StatementLog, error) {
+	log := statementLogOf(p.Request)
+	if log == nil {
+		return nil, fmt.Errorf("%s statement log requires the WithStatementLog option", ContainerPrettyName)
+	}
+	log.container = p.Container
+	return log, nil
+}
+
+// StatementLogModule provides the *StatementLog named "postgres" of the container.
+var StatementLogModule = fx.Provide(
+	fx.Annotate(
+		NewStatementLog,
+		fx.ResultTags(`name:"postgres"`),
+	),
+)
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const (
+	// ephemeralDataDir is the data directory mounted on tmpfs by WithEphemeralTuning.
+	ephemeralDataDir = "/var/lib/postgresql/ephemeral"
+
+	// ephemeralMaxConnections leaves room for parallel tests each holding a pool.
+	ephemeralMaxConnections = "500"
+)
+
+// WithEphemeralTuning trades durability for speed in test suites bound on
+// I/O: the data directory lives on tmpfs, writes are neither flushed nor
+// waited for, and the connection limit is raised. Data does not survive a
+// restart of the container, nor a crash of the server.
+func WithEphemeralTuning() testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if req.Env == nil {
+			req.Env = make(map[string]string)
+		}
+		req.Env["PGDATA"] = ephemeralDataDir
+		if req.Tmpfs == nil {
+			req.Tmpfs = make(map[string]string)
+		}
+		req.Tmpfs[ephemeralDataDir] = "rw"
+		if len(req.Cmd) == 0 {
+			req.Cmd = []string{"postgres"}
+		}
+		req.Cmd = append(req.Cmd,
+			"-c", "fsync=off",
+			"-c", "synchronous_commit=off",
+			"-c", "full_page_writes=off",
+			"-c", "max_connections="+ephemeralMaxConnections,
+		)
+		return nil
+	}
+}
@@ -0,0 +1,193 @@
+package mockestra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// DefaultPullConcurrency is the number of images Prefetch pulls at once.
+const DefaultPullConcurrency = 4
+
+// ImageClient is the subset of the Docker client Prefetch needs,
+// satisfied by *testcontainers.DockerClient.
+type ImageClient interface {
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+}
+
+// PrefetchOption configures Prefetch.
+type PrefetchOption func(*prefetchConfig)
+
+type prefetchConfig struct {
+	offline     bool
+	concurrency int
+	client      ImageClient
+}
+
+// Offline makes Prefetch fail fast with the list of images missing
+// from the local daemon instead of pulling them.
+func Offline() PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.offline = true
+	}
+}
+
+// WithPullConcurrency overrides how many images are pulled in parallel.
+func WithPullConcurrency(n int) PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithImageClient overrides the Docker client used to inspect and pull images.
+func WithImageClient(cli ImageClient) PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.client = cli
+	}
+}
+
+// MissingImagesError lists the images that are not available in the local daemon.
+type MissingImagesError struct {
+	Images []string
+}
+
+func (e *MissingImagesError) Error() string {
+	return fmt.Sprintf("%d image(s) missing from the local Docker daemon:\n  - %s", len(e.Images), strings.Join(e.Images, "\n  - "))
+}
+
+// Images returns the sorted, deduplicated images referenced by requests.
+// Derived containers such as the Hydra and Kratos -migrate containers run
+// from their module's image, so they are covered by it.
+func Images(requests []*testcontainers.GenericContainerRequest) []string {
+	var images []string
+	for _, req := range requests {
+		if req == nil || req.Image == "" || slices.Contains(images, req.Image) {
+			continue
+		}
+		images = append(images, req.Image)
+	}
+	slices.Sort(images)
+	return images
+}
+
+// Prefetch makes sure every image referenced by requests is present in the
+// local Docker daemon before any container is started, so a missing image
+// surfaces up front rather than as a pull timeout inside Actualize.
+// Missing images are pulled in parallel with progress logged through slog,
+// or reported as a *MissingImagesError when Offline is given.
+func Prefetch(ctx context.Context, requests []*testcontainers.GenericContainerRequest, opts ...PrefetchOption) error {
+	cfg := &prefetchConfig{concurrency: DefaultPullConcurrency}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.client == nil {
+		cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer cli.Close()
+		cfg.client = cli
+	}
+
+	var missing []string
+	for _, ref := range Images(requests) {
+		_, err := cfg.client.ImageInspect(ctx, ref)
+		switch {
+		case err == nil:
+			slog.Debug("Image is available locally", "image", ref)
+		case cerrdefs.IsNotFound(err):
+			missing = append(missing, ref)
+		default:
+			return fmt.Errorf("failed to inspect image %s: %w", ref, err)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if cfg.offline {
+		return &MissingImagesError{Images: missing}
+	}
+
+	slog.Info("Pulling missing images", "count", len(missing), "images", missing)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	semaphore := make(chan struct{}, max(cfg.concurrency, 1))
+	for _, ref := range missing {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if err := pullImage(ctx, cfg.client, ref); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// pullProgress is a single message of the Docker image pull stream.
+type pullProgress struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+func pullImage(ctx context.Context, cli ImageClient, ref string) error {
+	slog.Info("Pulling image", "image", ref)
+	stream, err := cli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer stream.Close()
+
+	decoder := json.NewDecoder(stream)
+	for {
+		var progress pullProgress
+		if err := decoder.Decode(&progress); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read pull progress of image %s: %w", ref, err)
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("failed to pull image %s: %s", ref, progress.Error)
+		}
+		slog.Debug("Pulling image", "image", ref, "layer", progress.ID, "status", progress.Status)
+	}
+	slog.Info("Pulled image", "image", ref)
+	return nil
+}
+
+type PrefetchParams struct {
+	fx.In
+	Requests []*testcontainers.GenericContainerRequest `group:"requests"`
+}
+
+// PrefetchModule runs Prefetch over every module request of the stack.
+// It builds the requests but starts no container, so it has to be placed
+// before any fx.Invoke that consumes containers.
+func PrefetchModule(opts ...PrefetchOption) fx.Option {
+	return fx.Invoke(func(p PrefetchParams) error {
+		return Prefetch(context.Background(), p.Requests, opts...)
+	})
+}
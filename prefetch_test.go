@@ -0,0 +1,127 @@
+package mockestra_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// fakeImageClient serves ImageInspect from a set of local images and records pulls.
+type fakeImageClient struct {
+	mu     sync.Mutex
+	local  map[string]bool
+	pulled []string
+}
+
+func (f *fakeImageClient) ImageInspect(ctx context.Context, ref string, _ ...client.ImageInspectOption) (image.InspectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.local[ref] {
+		return image.InspectResponse{}, cerrdefs.ErrNotFound
+	}
+	return image.InspectResponse{ID: ref}, nil
+}
+
+func (f *fakeImageClient) ImagePull(ctx context.Context, ref string, _ image.PullOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pulled = append(f.pulled, ref)
+	if strings.HasPrefix(ref, "broken") {
+		return io.NopCloser(strings.NewReader(`{"status":"Pulling fs layer","id":"abc"}{"error":"manifest unknown"}`)), nil
+	}
+	f.local[ref] = true
+	return io.NopCloser(strings.NewReader(`{"status":"Pulling fs layer","id":"abc"}{"status":"Pull complete","id":"abc"}`)), nil
+}
+
+func requestsFor(images ...string) []*testcontainers.GenericContainerRequest {
+	var requests []*testcontainers.GenericContainerRequest
+	for _, img := range images {
+		requests = append(requests, &testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{Image: img},
+		})
+	}
+	return requests
+}
+
+func TestImages(t *testing.T) {
+	images := mockestra.Images(requestsFor("postgres:17", "oryd/hydra:v2.3.0", "postgres:17", ""))
+	if !slices.Equal(images, []string{"oryd/hydra:v2.3.0", "postgres:17"}) {
+		t.Errorf("unexpected images %v", images)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	cli := &fakeImageClient{local: map[string]bool{"postgres:17": true}}
+	err := mockestra.Prefetch(t.Context(), requestsFor("postgres:17", "redis:8", "nats:2"), mockestra.WithImageClient(cli))
+	if err != nil {
+		t.Fatalf("Prefetch failed: %v", err)
+	}
+	slices.Sort(cli.pulled)
+	if !slices.Equal(cli.pulled, []string{"nats:2", "redis:8"}) {
+		t.Errorf("expected only missing images to be pulled, got %v", cli.pulled)
+	}
+}
+
+func TestPrefetchPullError(t *testing.T) {
+	cli := &fakeImageClient{local: map[string]bool{}}
+	err := mockestra.Prefetch(t.Context(), requestsFor("broken:1", "redis:8"), mockestra.WithImageClient(cli))
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("expected pull error to be reported, got %v", err)
+	}
+	if !cli.local["redis:8"] {
+		t.Error("expected the other images to be pulled regardless")
+	}
+}
+
+func TestPrefetchOffline(t *testing.T) {
+	cli := &fakeImageClient{local: map[string]bool{"postgres:17": true}}
+	err := mockestra.Prefetch(t.Context(), requestsFor("postgres:17", "redis:8", "nats:2"), mockestra.WithImageClient(cli), mockestra.Offline())
+
+	var missing *mockestra.MissingImagesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a MissingImagesError, got %v", err)
+	}
+	if !slices.Equal(missing.Images, []string{"nats:2", "redis:8"}) {
+		t.Errorf("unexpected missing images %v", missing.Images)
+	}
+	if len(cli.pulled) != 0 {
+		t.Errorf("expected nothing to be pulled in offline mode, got %v", cli.pulled)
+	}
+}
+
+func TestPrefetchModule(t *testing.T) {
+	newRequest := func(img string) func() *testcontainers.GenericContainerRequest {
+		return func() *testcontainers.GenericContainerRequest {
+			return &testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{Image: img},
+			}
+		}
+	}
+	cli := &fakeImageClient{local: map[string]bool{}}
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		mockestra.BuildContainerModule("first", fx.Provide(fx.Annotate(newRequest("first:1"), fx.ResultTags(`name:"first"`))))(),
+		mockestra.BuildContainerModule("second", fx.Provide(fx.Annotate(newRequest("second:1"), fx.ResultTags(`name:"second"`))))(),
+		mockestra.PrefetchModule(mockestra.WithImageClient(cli)),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	slices.Sort(cli.pulled)
+	if !slices.Equal(cli.pulled, []string{"first:1", "second:1"}) {
+		t.Errorf("expected every module image to be pulled, got %v", cli.pulled)
+	}
+}
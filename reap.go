@@ -0,0 +1,201 @@
+package mockestra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// Labels set on every mockestra container, identifying the stack and the run it belongs to.
+const (
+	LabelPrefix = "mockestra.prefix"
+	LabelModule = "mockestra.module"
+	LabelRunID  = "mockestra.run_id"
+	LabelPID    = "mockestra.pid"
+	LabelHost   = "mockestra.host"
+)
+
+// RunID identifies the current process in the LabelRunID label.
+var RunID = sync.OnceValue(func() string {
+	id, err := RandomPassword(8)
+	if err != nil {
+		return strconv.Itoa(os.Getpid())
+	}
+	return id
+})
+
+// Labels returns the labels identifying a resource of module in the stack
+// of prefix for the current run, e.g. to label a network shared by the stack
+// so that Reap cleans it up along with the containers.
+func Labels(prefix, module string) map[string]string {
+	host, _ := os.Hostname()
+	return map[string]string{
+		LabelPrefix: prefix,
+		LabelModule: module,
+		LabelRunID:  RunID(),
+		LabelPID:    strconv.Itoa(os.Getpid()),
+		LabelHost:   host,
+	}
+}
+
+// ReapClient is the subset of the Docker client Reap needs,
+// satisfied by *testcontainers.DockerClient.
+type ReapClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+}
+
+// ReapFilter selects the resources removed by Reap.
+type ReapFilter struct {
+	// Prefix restricts reaping to a single stack, every stack when empty.
+	Prefix string
+	// RunID restricts reaping to a single run, every run when empty.
+	RunID string
+	// IncludeLive also reaps resources of runs whose process is still
+	// alive, including the current one.
+	IncludeLive bool
+}
+
+// ReapOption configures Reap.
+type ReapOption func(*reapConfig)
+
+type reapConfig struct {
+	client ReapClient
+}
+
+// WithReapClient overrides the Docker client used to list and remove resources.
+func WithReapClient(cli ReapClient) ReapOption {
+	return func(c *reapConfig) {
+		c.client = cli
+	}
+}
+
+// Reap removes the containers, migration containers included, and networks
+// left behind by runs that did not shut their stack down, such as a panicking
+// or killed test. Only resources labeled by mockestra are considered, and
+// unless filter.IncludeLive is set, those of runs whose process is still alive
+// on this host, or that were started from another host, are kept.
+// It returns the names of the removed resources.
+func Reap(ctx context.Context, filter ReapFilter, opts ...ReapOption) ([]string, error) {
+	cfg := &reapConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.client == nil {
+		cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer cli.Close()
+		cfg.client = cli
+	}
+
+	args := filters.NewArgs(filters.Arg("label", LabelRunID))
+	if filter.Prefix != "" {
+		args.Add("label", fmt.Sprintf("%s=%s", LabelPrefix, filter.Prefix))
+	}
+	if filter.RunID != "" {
+		args.Add("label", fmt.Sprintf("%s=%s", LabelRunID, filter.RunID))
+	}
+
+	var (
+		reaped []string
+		errs   []error
+	)
+	containers, err := cfg.client.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		if !filter.IncludeLive && runAlive(c.Labels) {
+			continue
+		}
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		if err := cfg.client.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", name, err))
+			continue
+		}
+		slog.Info("Reaped container", "name", name, "prefix", c.Labels[LabelPrefix], "module", c.Labels[LabelModule], "run_id", c.Labels[LabelRunID])
+		reaped = append(reaped, name)
+	}
+
+	// networks go last, as they can only be removed once no container is attached
+	networks, err := cfg.client.NetworkList(ctx, network.ListOptions{Filters: args})
+	if err != nil {
+		return reaped, errors.Join(append(errs, fmt.Errorf("failed to list networks: %w", err))...)
+	}
+	for _, n := range networks {
+		if !filter.IncludeLive && runAlive(n.Labels) {
+			continue
+		}
+		if err := cfg.client.NetworkRemove(ctx, n.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove network %s: %w", n.Name, err))
+			continue
+		}
+		slog.Info("Reaped network", "name", n.Name, "prefix", n.Labels[LabelPrefix], "run_id", n.Labels[LabelRunID])
+		reaped = append(reaped, n.Name)
+	}
+	return reaped, errors.Join(errs...)
+}
+
+// runAlive reports whether the run that labeled a resource may still be using it.
+func runAlive(labels map[string]string) bool {
+	if labels[LabelRunID] == RunID() {
+		return true
+	}
+	if host, _ := os.Hostname(); labels[LabelHost] != host {
+		return true
+	}
+	pid, err := strconv.Atoi(labels[LabelPID])
+	if err != nil {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
+
+// autoReaped records the prefixes AutoReapModule has already reaped in this process.
+var autoReaped = struct {
+	sync.Mutex
+	prefixes map[string]bool
+}{prefixes: make(map[string]bool)}
+
+// AutoReapModule reaps the leftovers of dead runs for the stack prefix when
+// the first module request is built, so that a previously crashed run does
+// not fail the stack with container name conflicts.
+func AutoReapModule(opts ...ReapOption) fx.Option {
+	return StackCustomizers(func(tag string, req *testcontainers.GenericContainerRequest) error {
+		prefix := req.Labels[LabelPrefix]
+		autoReaped.Lock()
+		defer autoReaped.Unlock()
+		if autoReaped.prefixes[prefix] {
+			return nil
+		}
+		if _, err := Reap(context.Background(), ReapFilter{Prefix: prefix}, opts...); err != nil {
+			return fmt.Errorf("failed to reap leftovers of stack %s: %w", prefix, err)
+		}
+		autoReaped.prefixes[prefix] = true
+		return nil
+	})
+}
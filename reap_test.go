@@ -0,0 +1,135 @@
+package mockestra_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// fakeReapClient serves containers and networks filtered by label and records removals.
+type fakeReapClient struct {
+	containers []container.Summary
+	networks   []network.Summary
+	removed    []string
+}
+
+func matches(args filters.Args, labels map[string]string) bool {
+	for _, label := range args.Get("label") {
+		key, value, hasValue := strings.Cut(label, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeReapClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var result []container.Summary
+	for _, c := range f.containers {
+		if matches(options.Filters, c.Labels) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeReapClient) ContainerRemove(ctx context.Context, id string, _ container.RemoveOptions) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+func (f *fakeReapClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	var result []network.Summary
+	for _, n := range f.networks {
+		if matches(options.Filters, n.Labels) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeReapClient) NetworkRemove(ctx context.Context, id string) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+// deadLabels labels a resource as created by a run whose process is gone.
+func deadLabels(prefix, module string) map[string]string {
+	labels := mockestra.Labels(prefix, module)
+	labels[mockestra.LabelRunID] = "dead"
+	labels[mockestra.LabelPID] = "2147483646"
+	return labels
+}
+
+func TestReap(t *testing.T) {
+	cli := &fakeReapClient{
+		containers: []container.Summary{
+			{ID: "dead-postgres", Names: []string{"/mock-a-postgres"}, Labels: deadLabels("a", "postgres")},
+			{ID: "dead-migrate", Names: []string{"/mock-a-hydra-migrate"}, Labels: deadLabels("a", "hydra")},
+			{ID: "dead-other", Names: []string{"/mock-b-postgres"}, Labels: deadLabels("b", "postgres")},
+			{ID: "live", Names: []string{"/mock-a-redis"}, Labels: mockestra.Labels("a", "redis")},
+			{ID: "unlabeled", Names: []string{"/mock-a-valkey"}},
+		},
+		networks: []network.Summary{
+			{ID: "dead-network", Name: "mock-a", Labels: deadLabels("a", "network")},
+		},
+	}
+	reaped, err := mockestra.Reap(t.Context(), mockestra.ReapFilter{Prefix: "a"}, mockestra.WithReapClient(cli))
+	if err != nil {
+		t.Fatalf("Reap failed: %v", err)
+	}
+	if !slices.Equal(reaped, []string{"mock-a-postgres", "mock-a-hydra-migrate", "mock-a"}) {
+		t.Errorf("unexpected reaped resources %v", reaped)
+	}
+	if !slices.Equal(cli.removed, []string{"dead-postgres", "dead-migrate", "dead-network"}) {
+		t.Errorf("unexpected removals %v", cli.removed)
+	}
+
+	cli.removed = nil
+	if _, err := mockestra.Reap(t.Context(), mockestra.ReapFilter{Prefix: "a", IncludeLive: true}, mockestra.WithReapClient(cli)); err != nil {
+		t.Fatalf("Reap failed: %v", err)
+	}
+	if !slices.Contains(cli.removed, "live") {
+		t.Errorf("expected live run to be reaped with IncludeLive, got %v", cli.removed)
+	}
+}
+
+func TestModuleLabels(t *testing.T) {
+	var req *testcontainers.GenericContainerRequest
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(fx.Annotate("reap-test", fx.ResultTags(`name:"prefix"`))),
+		fx.Options(mockestra.Versions(map[string]string{"first": "1"})...),
+		testModule("first"),
+		fx.Populate(fx.Annotate(&req, fx.ParamTags(`name:"first"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	hostname, _ := os.Hostname()
+	for label, expected := range map[string]string{
+		mockestra.LabelPrefix: "reap-test",
+		mockestra.LabelModule: "first",
+		mockestra.LabelRunID:  mockestra.RunID(),
+		mockestra.LabelHost:   hostname,
+	} {
+		if req.Labels[label] != expected {
+			t.Errorf("expected label %s=%s, got %q", label, expected, req.Labels[label])
+		}
+	}
+	if req.Labels[mockestra.LabelPID] == "" {
+		t.Error("expected pid label to be set")
+	}
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/narwhl/mockestra/pki"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"redis"`
+	Container testcontainers.Container                `name:"redis"`
+	CA        *pki.CA                                 `optional:"true"`
+}
+
+// NewClient returns a client connected to the container, over TLSPort
+// verified against the stack CA when one is provided.
+// The client is closed when the app stops. Clusters and sentinels set up by
+// WithCluster or WithSentinel are reached through *Cluster and *Sentinel instead.
+func NewClient(p ClientParams) (*goredis.Client, error) {
+	if hasTopology(p.Request) {
+		return nil, fmt.Errorf("%s ClientModule does not apply to clusters and sentinels, connect through the *Cluster or *Sentinel named %q instead", ContainerPrettyName, Tag)
+	}
+	port := Port
+	if p.CA != nil {
+		port = TLSPort
+	}
+	addr, err := p.Container.PortEndpoint(context.Background(), nat.Port(port), "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	opts := &goredis.Options{Addr: addr}
+	if p.CA != nil {
+		host, _, _ := net.SplitHostPort(addr)
+		opts.TLSConfig = &tls.Config{
+			RootCAs:    p.CA.CertPool(),
+			ServerName: host,
+		}
+	}
+	client := goredis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return client.Close()
+		},
+	})
+	return client, nil
+}
+
+// ClientModule provides a *redis.Client named "redis" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"redis"`),
+	),
+)
@@ -0,0 +1,304 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// clusterMastersLabel carries the number of masters set by WithCluster,
+	// served by the first nodes, the other ones being replicas.
+	clusterMastersLabel = "mockestra.redis.cluster.masters"
+
+	// busPortsLabel carries the cluster bus ports of the nodes set by
+	// WithCluster, which are only reached from within the namespace.
+	busPortsLabel = "mockestra.redis.cluster.bus_ports"
+
+	clusterSlots = 16384
+)
+
+// WithCluster serves the container as the first node of a Redis Cluster of
+// masters masters, each followed by replicasPerMaster replicas, and provides
+// it as the *Cluster named "redis". Nodes announce the loopback address and
+// the host ports they are published on, so that host-side clients follow
+// MOVED and ASK redirects; connect through the *Cluster rather than Port,
+// which is no longer served. It requires Redis 7 or later.
+func WithCluster(masters, replicasPerMaster int) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if masters < 1 || replicasPerMaster < 0 {
+			return fmt.Errorf("invalid %s cluster of %d masters with %d replicas each", ContainerPrettyName, masters, replicasPerMaster)
+		}
+		n := masters * (1 + replicasPerMaster)
+		ports, err := freePorts(2 * n)
+		if err != nil {
+			return err
+		}
+		if err := withTopology(req, ports[:n], nil); err != nil {
+			return err
+		}
+		req.Labels[clusterMastersLabel] = strconv.Itoa(masters)
+		req.Labels[busPortsLabel] = joinPorts(ports[n:])
+		req.Cmd = append(req.Cmd, clusterArgs(ports[n])...)
+		return nil
+	}
+}
+
+func clusterArgs(busPort int) []string {
+	return []string{
+		"--cluster-enabled", "yes",
+		"--cluster-port", strconv.Itoa(busPort),
+		"--cluster-announce-ip", mockestra.LoopbackAddress,
+		"--cluster-node-timeout", "5000",
+	}
+}
+
+// ClusterNode is a node of the cluster set up by WithCluster.
+type ClusterNode struct {
+	Container testcontainers.Container
+	// Addr is the address the node announces, reachable from the host.
+	Addr string
+	// ID is the cluster node ID.
+	ID string
+}
+
+// Cluster is the Redis Cluster set up by WithCluster, its first node being
+// the container.
+type Cluster struct {
+	Nodes []*ClusterNode
+}
+
+// Addrs returns the addresses of every node, to seed cluster clients with.
+func (c *Cluster) Addrs() []string {
+	addrs := make([]string, len(c.Nodes))
+	for i, node := range c.Nodes {
+		addrs[i] = node.Addr
+	}
+	return addrs
+}
+
+// NewClient returns a cluster client seeded with every node, which the
+// caller closes.
+func (c *Cluster) NewClient() *goredis.ClusterClient {
+	return goredis.NewClusterClient(&goredis.ClusterOptions{Addrs: c.Addrs()})
+}
+
+// Masters returns the addresses of the nodes currently serving slots.
+func (c *Cluster) Masters(ctx context.Context) ([]string, error) {
+	nodes, err := c.clusterNodes(ctx, c.Nodes[0].Addr)
+	if err != nil {
+		return nil, err
+	}
+	var masters []string
+	for _, node := range nodes {
+		if node.is("master") && !node.is("fail") {
+			masters = append(masters, node.addr)
+		}
+	}
+	slices.Sort(masters)
+	return masters, nil
+}
+
+// Failover promotes a replica of the master at addr with CLUSTER FAILOVER,
+// as when the master is taken down for maintenance, and returns the address
+// of the replica once it serves the slots of the former master and the
+// former master follows it.
+func (c *Cluster) Failover(ctx context.Context, addr string) (string, error) {
+	nodes, err := c.clusterNodes(ctx, addr)
+	if err != nil {
+		return "", err
+	}
+	var master, replica *clusterNodeLine
+	for _, node := range nodes {
+		if node.is("myself") {
+			master = &node
+		}
+	}
+	if master == nil || !master.is("master") {
+		return "", fmt.Errorf("%s cluster node %s is not a master", ContainerPrettyName, addr)
+	}
+	for _, node := range nodes {
+		if node.master == master.id && !node.is("fail") {
+			replica = &node
+			break
+		}
+	}
+	if replica == nil {
+		return "", fmt.Errorf("%s cluster master %s has no replica to fail over to", ContainerPrettyName, addr)
+	}
+
+	client := goredis.NewClient(&goredis.Options{Addr: replica.addr})
+	defer client.Close()
+	if err := client.Do(ctx, "CLUSTER", "FAILOVER").Err(); err != nil {
+		return "", fmt.Errorf("failed to fail over %s cluster master %s to %s: %w", ContainerPrettyName, addr, replica.addr, err)
+	}
+	err = poll(ctx, func(ctx context.Context) error {
+		nodes, err := c.clusterNodes(ctx, addr)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			if node.is("myself") && node.master == replica.id {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s cluster node %s does not follow %s yet", ContainerPrettyName, addr, replica.addr)
+	})
+	if err != nil {
+		return "", err
+	}
+	return replica.addr, nil
+}
+
+// clusterNodeLine is a node as listed by CLUSTER NODES.
+type clusterNodeLine struct {
+	id     string
+	addr   string
+	flags  []string
+	master string
+}
+
+func (n clusterNodeLine) is(flag string) bool {
+	return slices.Contains(n.flags, flag)
+}
+
+// parseClusterNodes parses the reply of CLUSTER NODES, whose lines read
+// "<id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv>
+// <config-epoch> <link-state> [<slot> ...]".
+func parseClusterNodes(reply string) []clusterNodeLine {
+	var nodes []clusterNodeLine
+	for line := range strings.Lines(reply) {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		addr, _, _ := strings.Cut(fields[1], "@")
+		nodes = append(nodes, clusterNodeLine{
+			id:     fields[0],
+			addr:   addr,
+			flags:  strings.Split(fields[2], ","),
+			master: fields[3],
+		})
+	}
+	return nodes
+}
+
+// clusterNodes returns the nodes as seen by the node at addr.
+func (c *Cluster) clusterNodes(ctx context.Context, addr string) ([]clusterNodeLine, error) {
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	defer client.Close()
+	reply, err := client.ClusterNodes(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s cluster nodes from %s: %w", ContainerPrettyName, addr, err)
+	}
+	return parseClusterNodes(reply), nil
+}
+
+// actualizeCluster starts the nodes after the container, then has them meet,
+// assigns the slots evenly to the masters and attaches the replicas to them
+// in turn.
+func actualizeCluster(ctx context.Context, p TopologyParams) (*Cluster, []testcontainers.Container, error) {
+	ports := labelPorts(p.Request, nodePortsLabel)
+	busPorts := labelPorts(p.Request, busPortsLabel)
+	masters, _ := strconv.Atoi(p.Request.Labels[clusterMastersLabel])
+
+	cluster := &Cluster{Nodes: []*ClusterNode{{Container: p.Container, Addr: hostAddr(ports[0])}}}
+	var containers []testcontainers.Container
+	for i := 1; i < len(ports); i++ {
+		c, err := runInNamespace(ctx, p, fmt.Sprintf("node-%d", i),
+			append([]string{"redis-server", "--port", strconv.Itoa(ports[i])}, clusterArgs(busPorts[i])...),
+			nil,
+			wait.ForLog(readyLog).AsRegexp(),
+		)
+		if err != nil {
+			terminate(ctx, containers)
+			return nil, nil, fmt.Errorf("an error occurred while instantiating %s cluster node %d: %w", ContainerPrettyName, i, err)
+		}
+		containers = append(containers, c)
+		cluster.Nodes = append(cluster.Nodes, &ClusterNode{Container: c, Addr: hostAddr(ports[i])})
+	}
+
+	if err := cluster.form(ctx, masters, busPorts); err != nil {
+		terminate(ctx, containers)
+		return nil, nil, fmt.Errorf("an error occurred while forming %s cluster: %w", ContainerPrettyName, err)
+	}
+	return cluster, containers, nil
+}
+
+func (c *Cluster) form(ctx context.Context, masters int, busPorts []int) error {
+	clients := make([]*goredis.Client, len(c.Nodes))
+	for i, node := range c.Nodes {
+		clients[i] = goredis.NewClient(&goredis.Options{Addr: node.Addr})
+		defer clients[i].Close()
+		id, err := clients[i].Do(ctx, "CLUSTER", "MYID").Text()
+		if err != nil {
+			return err
+		}
+		node.ID = id
+	}
+
+	for i, node := range c.Nodes[1:] {
+		host, port, _ := net.SplitHostPort(node.Addr)
+		if err := clients[0].Do(ctx, "CLUSTER", "MEET", host, port, busPorts[i+1]).Err(); err != nil {
+			return err
+		}
+	}
+	for i := range masters {
+		if err := clients[i].ClusterAddSlotsRange(ctx, i*clusterSlots/masters, (i+1)*clusterSlots/masters-1).Err(); err != nil {
+			return err
+		}
+	}
+
+	// replicas only follow masters they already know about
+	err := poll(ctx, func(ctx context.Context) error {
+		for i, client := range clients {
+			reply, err := client.ClusterNodes(ctx).Result()
+			if err != nil {
+				return err
+			}
+			if known := len(parseClusterNodes(reply)); known < len(c.Nodes) {
+				return fmt.Errorf("node %d knows %d of %d nodes", i, known, len(c.Nodes))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := masters; i < len(c.Nodes); i++ {
+		if err := clients[i].ClusterReplicate(ctx, c.Nodes[(i-masters)%masters].ID).Err(); err != nil {
+			return err
+		}
+	}
+
+	return poll(ctx, func(ctx context.Context) error {
+		for i, client := range clients {
+			info, err := client.ClusterInfo(ctx).Result()
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(info, "cluster_state:ok") {
+				return fmt.Errorf("node %d does not report cluster_state:ok", i)
+			}
+			if i < masters {
+				continue
+			}
+			replication, err := client.Info(ctx, "replication").Result()
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(replication, "master_link_status:up") {
+				return fmt.Errorf("replica node %d is not in sync with its master", i)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+)
+
+// actualizeFake serves the module from an in-process miniredis instead of
+// a container, for stacks switched with mockestra.InProcess.
+func actualizeFake(p ContainerParams) (Result, error) {
+	if slices.Contains(p.Request.ExposedPorts, TLSPort) {
+		return Result{}, fmt.Errorf("the in-process %s fake does not serve TLS, leave out either the stack CA or mockestra.InProcess", ContainerPrettyName)
+	}
+	if hasTopology(p.Request) {
+		return Result{}, fmt.Errorf("the in-process %s fake does not serve clusters or sentinels, leave out either the topology or mockestra.InProcess", ContainerPrettyName)
+	}
+	server, err := miniredis.Run()
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while starting in-process %s: %w", ContainerPrettyName, err)
+	}
+	c, err := mockestra.FakeContainer(Tag, server.Addr(), Port)
+	if err == nil {
+		err = mockestra.RunPostReadyHooks(context.Background(), p.Request, c)
+	}
+	if err != nil {
+		server.Close()
+		return Result{}, err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			slog.Info(fmt.Sprintf("%s in-process fake is running at", ContainerPrettyName), "addr", server.Addr())
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			server.Close()
+			slog.Info(fmt.Sprintf("%s in-process fake is stopped", ContainerPrettyName))
+			return nil
+		},
+	})
+	return Result{
+		Container:      c,
+		ContainerGroup: c,
+	}, nil
+}
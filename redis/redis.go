@@ -1,12 +1,15 @@
 package redis
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/docker/go-connections/nat"
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"go.uber.org/fx"
@@ -17,14 +20,66 @@ const (
 	Image = "redis"
 	Port  = "6379/tcp"
 
+	// TLSPort is served alongside Port once the stack CA is in place,
+	// so plain clients keep working.
+	TLSPort = "6380/tcp"
+
 	ContainerPrettyName = "Redis"
+
+	// Container paths for TLS material issued by the stack CA
+	containerCAPath   = "/tls/ca.pem"
+	containerCertPath = "/tls/server.crt"
+	containerKeyPath  = "/tls/server.key"
 )
 
+// WithCA enables a TLS listener on TLSPort with a leaf certificate issued
+// by ca. Client certificates are verified when presented but not required.
+func WithCA(ca *pki.CA) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		serverCert, err := ca.IssueServer(Tag, pki.ServerNames(req.Name, req.NetworkAliases)...)
+		if err != nil {
+			return fmt.Errorf("failed to issue %s server certificate: %w", ContainerPrettyName, err)
+		}
+		req.Files = append(req.Files,
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(ca.CertPEM),
+				ContainerFilePath: containerCAPath,
+				FileMode:          0o644,
+			},
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.CertPEM),
+				ContainerFilePath: containerCertPath,
+				FileMode:          0o644,
+			},
+			// the server drops privileges to the redis user, which has to read the key
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.KeyPEM),
+				ContainerFilePath: containerKeyPath,
+				FileMode:          0o644,
+			},
+		)
+		if len(req.Cmd) == 0 {
+			req.Cmd = []string{"redis-server"}
+		}
+		_, tlsPort := nat.SplitProtoPort(TLSPort)
+		req.Cmd = append(req.Cmd,
+			"--tls-port", tlsPort,
+			"--tls-cert-file", containerCertPath,
+			"--tls-key-file", containerKeyPath,
+			"--tls-ca-cert-file", containerCAPath,
+			"--tls-auth-clients", "optional",
+		)
+		req.ExposedPorts = append(req.ExposedPorts, TLSPort)
+		return nil
+	}
+}
+
 type RequestParams struct {
 	fx.In
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"redis_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"redis"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
@@ -48,6 +103,15 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		}
 	}
 
+	if p.CA != nil {
+		if hasTopology(&r) {
+			return nil, fmt.Errorf("%s cluster and sentinel nodes only serve plain connections, leave out either the topology or the stack CA", ContainerPrettyName)
+		}
+		if err := WithCA(p.CA).Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
@@ -55,6 +119,7 @@ type ContainerParams struct {
 	fx.In
 	Lifecycle fx.Lifecycle
 	Request   *testcontainers.GenericContainerRequest `name:"redis"`
+	InProcess bool                                    `name:"in_process" optional:"true"`
 }
 
 type Result struct {
@@ -64,6 +129,9 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
+	if p.InProcess {
+		return actualizeFake(p)
+	}
 	c, err := testcontainers.GenericContainer(context.Background(), *p.Request)
 	if err != nil {
 		return Result{}, fmt.Errorf("an error occurred while instantiating %s container: %w", ContainerPrettyName, err)
@@ -104,5 +172,6 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"redis"`),
 		),
 		Actualize,
+		ActualizeTopology,
 	),
 )
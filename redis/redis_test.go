@@ -2,10 +2,14 @@ package redis_test
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	container "github.com/narwhl/mockestra/redis"
 	"github.com/testcontainers/testcontainers-go"
 	"go.uber.org/fx"
@@ -51,3 +55,239 @@ func TestRedisModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestClientModule(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"8-alpine",
+				fx.ResultTags(`name:"redis_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("redis-client-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client *redis.Client `name:"redis"`
+		}) {
+			if err := params.Client.Ping(t.Context()).Err(); err != nil {
+				t.Errorf("failed to ping redis: %v", err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
+
+func TestInProcess(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"8-alpine",
+				fx.ResultTags(`name:"redis_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("redis-inprocess-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		mockestra.InProcess(),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client *redis.Client `name:"redis"`
+		}) {
+			if err := params.Client.Set(t.Context(), "key", "value", 0).Err(); err != nil {
+				t.Errorf("failed to set key: %v", err)
+			}
+			if value, err := params.Client.Get(t.Context(), "key").Result(); err != nil || value != "value" {
+				t.Errorf("expected value, got %q, %v", value, err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
+
+func TestWithClusterOption(t *testing.T) {
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:         "redis-cluster-option-test",
+			ExposedPorts: []string{container.Port},
+		},
+	}
+	if err := container.WithCluster(3, 1)(req); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+	if len(req.ExposedPorts) != 6 || slices.Contains(req.ExposedPorts, container.Port) {
+		t.Errorf("expected the ports of 6 nodes instead of %s, got %v", container.Port, req.ExposedPorts)
+	}
+	if !slices.Contains(req.Cmd, "--cluster-enabled") {
+		t.Errorf("expected cluster mode to be enabled, got %v", req.Cmd)
+	}
+	if err := container.WithSentinel(1)(req); err == nil {
+		t.Error("expected sentinels on a cluster to fail")
+	}
+	if err := container.WithCluster(0, 1)(&testcontainers.GenericContainerRequest{}); err == nil {
+		t.Error("expected a cluster without masters to fail")
+	}
+}
+
+func TestWithSentinelOption(t *testing.T) {
+	req := &testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:         "redis-sentinel-option-test",
+			ExposedPorts: []string{container.Port},
+		},
+	}
+	if err := container.WithSentinel(3)(req); err != nil {
+		t.Fatalf("Customize failed: %v", err)
+	}
+	if len(req.ExposedPorts) != 5 || slices.Contains(req.ExposedPorts, container.Port) {
+		t.Errorf("expected the ports of 2 nodes and 3 sentinels instead of %s, got %v", container.Port, req.ExposedPorts)
+	}
+	if err := container.WithSentinel(0)(&testcontainers.GenericContainerRequest{}); err == nil {
+		t.Error("expected no sentinels to fail")
+	}
+}
+
+func TestTopologyWithoutPlainClient(t *testing.T) {
+	ca, err := pki.NewCA("redis topology test CA")
+	if err != nil {
+		t.Fatalf("NewCA failed: %v", err)
+	}
+	_, err = container.New(container.RequestParams{
+		Prefix:  "redis-topology-test",
+		Version: "8-alpine",
+		Opts:    []testcontainers.ContainerCustomizer{container.WithCluster(1, 0)},
+		CA:      ca,
+	})
+	if err == nil {
+		t.Error("expected a cluster with a stack CA to fail")
+	}
+
+	req, err := container.New(container.RequestParams{
+		Prefix:  "redis-topology-test",
+		Version: "8-alpine",
+		Opts:    []testcontainers.ContainerCustomizer{container.WithSentinel(1)},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_, err = container.NewClient(container.ClientParams{Request: req})
+	if err == nil || !strings.Contains(err.Error(), "*Sentinel") {
+		t.Errorf("expected the client of a sentinel setup to point to *Sentinel, got %v", err)
+	}
+}
+
+func TestWithCluster(t *testing.T) {
+	var cluster *container.Cluster
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"8-alpine",
+				fx.ResultTags(`name:"redis_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("redis-cluster-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(container.WithCluster(3, 1)),
+		fx.Populate(fx.Annotate(&cluster, fx.ParamTags(`name:"redis"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	client := cluster.NewClient()
+	defer client.Close()
+	keys := []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}
+	for _, key := range keys {
+		if err := client.Set(t.Context(), key, key, 0).Err(); err != nil {
+			t.Fatalf("failed to set %s: %v", key, err)
+		}
+	}
+
+	masters, err := cluster.Masters(t.Context())
+	if err != nil {
+		t.Fatalf("failed to list masters: %v", err)
+	}
+	if len(masters) != 3 {
+		t.Fatalf("expected 3 masters, got %v", masters)
+	}
+	promoted, err := cluster.Failover(t.Context(), masters[0])
+	if err != nil {
+		t.Fatalf("failed to fail over %s: %v", masters[0], err)
+	}
+	masters, err = cluster.Masters(t.Context())
+	if err != nil {
+		t.Fatalf("failed to list masters: %v", err)
+	}
+	if !slices.Contains(masters, promoted) {
+		t.Errorf("expected %s to be a master, got %v", promoted, masters)
+	}
+	for _, key := range keys {
+		if value, err := client.Get(t.Context(), key).Result(); err != nil || value != key {
+			t.Errorf("expected %s after failover, got %q, %v", key, value, err)
+		}
+	}
+}
+
+func TestWithSentinel(t *testing.T) {
+	var sentinel *container.Sentinel
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"8-alpine",
+				fx.ResultTags(`name:"redis_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("redis-sentinel-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(container.WithSentinel(3)),
+		fx.Populate(fx.Annotate(&sentinel, fx.ParamTags(`name:"redis"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	client := sentinel.NewClient()
+	defer client.Close()
+	if err := client.Set(t.Context(), "key", "value", 0).Err(); err != nil {
+		t.Fatalf("failed to set key: %v", err)
+	}
+
+	previous, err := sentinel.MasterAddr(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get master address: %v", err)
+	}
+	promoted, err := sentinel.Failover(t.Context())
+	if err != nil {
+		t.Fatalf("failed to fail over: %v", err)
+	}
+	if promoted == previous {
+		t.Errorf("expected a new master, got %s again", promoted)
+	}
+
+	failedOver := sentinel.NewClient()
+	defer failedOver.Close()
+	if err := failedOver.Set(t.Context(), "key", "updated", 0).Err(); err != nil {
+		t.Errorf("failed to set key on promoted master: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// SentinelMasterName is the name sentinels set up by WithSentinel monitor
+	// the master under.
+	SentinelMasterName = "mockestra"
+
+	// sentinelConfigPath is in the working directory of the image, which
+	// the entrypoint hands over to the redis user so that sentinels can
+	// rewrite their configuration.
+	sentinelConfigPath = "/data/sentinel.conf"
+)
+
+// WithSentinel serves the container as a master with a replica to fail over
+// to, monitored by n sentinels, and provides them as the *Sentinel named
+// "redis". Nodes and sentinels announce the loopback address and the host
+// ports they are published on, so that host-side clients reach the master
+// sentinels report; connect through the *Sentinel rather than Port, which is
+// no longer served.
+func WithSentinel(n int) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of %s sentinels: %d", ContainerPrettyName, n)
+		}
+		ports, err := freePorts(2 + n)
+		if err != nil {
+			return err
+		}
+		return withTopology(req, ports[:2], ports[2:])
+	}
+}
+
+// sentinelConfig renders the configuration of a sentinel listening on port,
+// monitoring the master on masterPort with a majority of sentinels as quorum.
+func sentinelConfig(port, masterPort, sentinels int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "port %d\n", port)
+	b.WriteString("protected-mode no\n")
+	fmt.Fprintf(&b, "sentinel announce-ip %s\n", mockestra.LoopbackAddress)
+	fmt.Fprintf(&b, "sentinel monitor %s %s %d %d\n", SentinelMasterName, mockestra.LoopbackAddress, masterPort, sentinels/2+1)
+	fmt.Fprintf(&b, "sentinel down-after-milliseconds %s 5000\n", SentinelMasterName)
+	fmt.Fprintf(&b, "sentinel failover-timeout %s 10000\n", SentinelMasterName)
+	return b.String()
+}
+
+// Sentinel is the master, replica and sentinels set up by WithSentinel.
+type Sentinel struct {
+	// Nodes are the master and the replica, in the order they were started.
+	Nodes []testcontainers.Container
+	// Sentinels are the sentinel processes.
+	Sentinels []testcontainers.Container
+
+	addrs []string
+}
+
+// Addrs returns the addresses of the sentinels, reachable from the host.
+func (s *Sentinel) Addrs() []string {
+	return append([]string(nil), s.addrs...)
+}
+
+// NewClient returns a client of the master the sentinels report, following
+// failovers, which the caller closes.
+func (s *Sentinel) NewClient() *goredis.Client {
+	return goredis.NewFailoverClient(&goredis.FailoverOptions{
+		MasterName:    SentinelMasterName,
+		SentinelAddrs: s.Addrs(),
+	})
+}
+
+// MasterAddr returns the address of the master as reported by the first sentinel.
+func (s *Sentinel) MasterAddr(ctx context.Context) (string, error) {
+	client := goredis.NewSentinelClient(&goredis.Options{Addr: s.addrs[0]})
+	defer client.Close()
+	addr, err := client.GetMasterAddrByName(ctx, SentinelMasterName).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s master address from sentinel: %w", ContainerPrettyName, err)
+	}
+	return net.JoinHostPort(addr[0], addr[1]), nil
+}
+
+// Failover has the sentinels promote the replica with SENTINEL FAILOVER and
+// returns its address once every sentinel reports it as the master and it
+// accepts writes.
+func (s *Sentinel) Failover(ctx context.Context) (string, error) {
+	previous, err := s.MasterAddr(ctx)
+	if err != nil {
+		return "", err
+	}
+	client := goredis.NewSentinelClient(&goredis.Options{Addr: s.addrs[0]})
+	defer client.Close()
+	if err := client.Failover(ctx, SentinelMasterName).Err(); err != nil {
+		return "", fmt.Errorf("failed to fail over %s master %s: %w", ContainerPrettyName, previous, err)
+	}
+
+	var master string
+	err = poll(ctx, func(ctx context.Context) error {
+		for _, addr := range s.addrs {
+			sentinel := goredis.NewSentinelClient(&goredis.Options{Addr: addr})
+			reply, err := sentinel.GetMasterAddrByName(ctx, SentinelMasterName).Result()
+			sentinel.Close()
+			if err != nil {
+				return err
+			}
+			if master = net.JoinHostPort(reply[0], reply[1]); master == previous {
+				return fmt.Errorf("sentinel %s still reports %s as master", addr, previous)
+			}
+		}
+		node := goredis.NewClient(&goredis.Options{Addr: master})
+		defer node.Close()
+		role, err := node.Do(ctx, "ROLE").Slice()
+		if err != nil {
+			return err
+		}
+		if len(role) == 0 || role[0] != "master" {
+			return fmt.Errorf("%s is not promoted yet", master)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return master, nil
+}
+
+// actualizeSentinel starts the replica and the sentinels after the container,
+// then waits for every sentinel to discover the replica and the other sentinels.
+func actualizeSentinel(ctx context.Context, p TopologyParams) (*Sentinel, []testcontainers.Container, error) {
+	ports := labelPorts(p.Request, nodePortsLabel)
+	sentinelPorts := labelPorts(p.Request, sentinelPortsLabel)
+
+	replica, err := runInNamespace(ctx, p, "replica",
+		[]string{
+			"redis-server",
+			"--port", strconv.Itoa(ports[1]),
+			"--replicaof", mockestra.LoopbackAddress, strconv.Itoa(ports[0]),
+			"--replica-announce-ip", mockestra.LoopbackAddress,
+		},
+		nil,
+		wait.ForLog("MASTER <-> REPLICA sync: Finished with success"),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("an error occurred while instantiating %s replica: %w", ContainerPrettyName, err)
+	}
+	containers := []testcontainers.Container{replica}
+	sentinel := &Sentinel{Nodes: []testcontainers.Container{p.Container, replica}}
+	for i, port := range sentinelPorts {
+		c, err := runInNamespace(ctx, p, fmt.Sprintf("sentinel-%d", i),
+			[]string{"redis-server", sentinelConfigPath, "--sentinel"},
+			[]testcontainers.ContainerFile{{
+				Reader:            strings.NewReader(sentinelConfig(port, ports[0], len(sentinelPorts))),
+				ContainerFilePath: sentinelConfigPath,
+				FileMode:          0o644,
+			}},
+			wait.ForLog(`\+monitor master`).AsRegexp(),
+		)
+		if err != nil {
+			terminate(ctx, containers)
+			return nil, nil, fmt.Errorf("an error occurred while instantiating %s sentinel %d: %w", ContainerPrettyName, i, err)
+		}
+		containers = append(containers, c)
+		sentinel.Sentinels = append(sentinel.Sentinels, c)
+		sentinel.addrs = append(sentinel.addrs, hostAddr(port))
+	}
+
+	err = poll(ctx, func(ctx context.Context) error {
+		for _, addr := range sentinel.addrs {
+			client := goredis.NewSentinelClient(&goredis.Options{Addr: addr})
+			master, err := client.Master(ctx, SentinelMasterName).Result()
+			client.Close()
+			if err != nil {
+				return err
+			}
+			if master["num-slaves"] != "1" || master["num-other-sentinels"] != strconv.Itoa(len(sentinelPorts)-1) {
+				return fmt.Errorf("sentinel %s knows %s replicas and %s other sentinels", addr, master["num-slaves"], master["num-other-sentinels"])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		terminate(ctx, containers)
+		return nil, nil, fmt.Errorf("an error occurred while waiting for %s sentinels: %w", ContainerPrettyName, err)
+	}
+	return sentinel, containers, nil
+}
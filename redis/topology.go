@@ -0,0 +1,227 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/fx"
+)
+
+const (
+	// nodePortsLabel carries the ports of the nodes set by WithCluster or
+	// WithSentinel, the first one served by the container itself. The other
+	// nodes run in the network namespace of the container, where every node
+	// listens on the port it is published on, so that the loopback address
+	// nodes announce to each other is reachable from the host as well.
+	nodePortsLabel = "mockestra.redis.node_ports"
+
+	// sentinelPortsLabel carries the ports of the sentinels set by WithSentinel.
+	sentinelPortsLabel = "mockestra.redis.sentinel_ports"
+
+	// topologyTimeout bounds starting the nodes of a topology and waiting
+	// for them to agree on it.
+	topologyTimeout = 60 * time.Second
+
+	readyLog = `\* Ready to accept connections`
+)
+
+// withTopology serves the first of nodes on the container instead of Port,
+// and publishes the ports of nodes and sentinels on the same host ports.
+func withTopology(req *testcontainers.GenericContainerRequest, nodes, sentinels []int) error {
+	if hasTopology(req) {
+		return fmt.Errorf("%s cluster and sentinel options are mutually exclusive and applied once", ContainerPrettyName)
+	}
+	req.ExposedPorts = slices.DeleteFunc(req.ExposedPorts, func(port string) bool {
+		return port == Port
+	})
+	for _, port := range slices.Concat(nodes, sentinels) {
+		if err := mockestra.WithHostPort(fmt.Sprintf("%d/tcp", port), port)(req); err != nil {
+			return err
+		}
+	}
+	req.Labels[nodePortsLabel] = joinPorts(nodes)
+	if len(sentinels) > 0 {
+		req.Labels[sentinelPortsLabel] = joinPorts(sentinels)
+	}
+	if len(req.Cmd) == 0 {
+		req.Cmd = []string{"redis-server"}
+	}
+	req.Cmd = append(req.Cmd, "--port", strconv.Itoa(nodes[0]))
+	req.WaitingFor = wait.ForAll(
+		wait.ForListeningPort(nat.Port(fmt.Sprintf("%d/tcp", nodes[0]))).WithStartupTimeout(time.Second*10),
+		wait.ForLog(readyLog).AsRegexp(),
+	)
+	return nil
+}
+
+// hasTopology reports whether req is set up by WithCluster or WithSentinel.
+func hasTopology(req *testcontainers.GenericContainerRequest) bool {
+	_, ok := req.Labels[nodePortsLabel]
+	return ok
+}
+
+// freePorts returns n distinct ports free on the host.
+func freePorts(n int) ([]int, error) {
+	ports := make([]int, n)
+	for i := range ports {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate free port for %s: %w", ContainerPrettyName, err)
+		}
+		// held until every port is allocated, so that none is handed out twice
+		defer listener.Close()
+		ports[i] = listener.Addr().(*net.TCPAddr).Port
+	}
+	return ports, nil
+}
+
+func joinPorts(ports []int) string {
+	values := make([]string, len(ports))
+	for i, port := range ports {
+		values[i] = strconv.Itoa(port)
+	}
+	return strings.Join(values, ",")
+}
+
+// labelPorts returns the ports carried by label of req.
+func labelPorts(req *testcontainers.GenericContainerRequest, label string) []int {
+	var ports []int
+	for value := range strings.SplitSeq(req.Labels[label], ",") {
+		if port, err := strconv.Atoi(value); err == nil {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// hostAddr returns the address a node or sentinel announces on port.
+func hostAddr(port int) string {
+	return net.JoinHostPort(mockestra.LoopbackAddress, strconv.Itoa(port))
+}
+
+// poll runs check until it succeeds, returning its last error once ctx is done.
+func poll(ctx context.Context, check func(context.Context) error) error {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+type TopologyParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"redis"`
+	Container testcontainers.Container                `name:"redis"`
+}
+
+type TopologyResult struct {
+	fx.Out
+	// Cluster is nil unless the container is set up by WithCluster.
+	Cluster *Cluster `name:"redis"`
+	// Sentinel is nil unless the container is set up by WithSentinel.
+	Sentinel        *Sentinel                  `name:"redis"`
+	ContainerGroups []testcontainers.Container `group:"containers,flatten"`
+}
+
+// ActualizeTopology starts the nodes and sentinels set by WithCluster or
+// WithSentinel in the network namespace of the container once it is running,
+// and waits for them to agree on the topology.
+func ActualizeTopology(p TopologyParams) (TopologyResult, error) {
+	if !hasTopology(p.Request) {
+		return TopologyResult{}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), topologyTimeout)
+	defer cancel()
+
+	var (
+		result TopologyResult
+		addrs  []string
+		err    error
+	)
+	if _, ok := p.Request.Labels[clusterMastersLabel]; ok {
+		result.Cluster, result.ContainerGroups, err = actualizeCluster(ctx, p)
+		if err == nil {
+			addrs = result.Cluster.Addrs()
+		}
+	} else {
+		result.Sentinel, result.ContainerGroups, err = actualizeSentinel(ctx, p)
+		if err == nil {
+			addrs = result.Sentinel.Addrs()
+		}
+	}
+	if err != nil {
+		return TopologyResult{}, err
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if result.Cluster != nil {
+				slog.Info(fmt.Sprintf("%s cluster is running at", ContainerPrettyName), "addrs", addrs)
+			} else {
+				slog.Info(fmt.Sprintf("%s sentinels are running at", ContainerPrettyName), "addrs", addrs)
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := terminate(ctx, result.ContainerGroups)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("an error occurred while terminating %s topology", ContainerPrettyName), "error", err)
+			} else {
+				slog.Info(fmt.Sprintf("%s topology is terminated", ContainerPrettyName))
+			}
+			return err
+		},
+	})
+	return result, nil
+}
+
+// runInNamespace starts a process of the topology named name in the network
+// namespace of the container.
+func runInNamespace(ctx context.Context, p TopologyParams, name string, cmd []string, files []testcontainers.ContainerFile, waitFor wait.Strategy) (testcontainers.Container, error) {
+	namespace := container.NetworkMode("container:" + p.Container.GetContainerID())
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:   fmt.Sprintf("%s-%s", p.Request.Name, name),
+			Image:  p.Request.Image,
+			Cmd:    cmd,
+			Files:  files,
+			Labels: mockestra.Labels(p.Request.Labels[mockestra.LabelPrefix], Tag),
+			HostConfigModifier: func(hostConfig *container.HostConfig) {
+				hostConfig.NetworkMode = namespace
+			},
+			WaitingFor: waitFor,
+		},
+		Started: true,
+	})
+}
+
+func terminate(ctx context.Context, containers []testcontainers.Container) error {
+	var errs []error
+	for _, c := range containers {
+		if err := c.Terminate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
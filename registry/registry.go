@@ -1,11 +1,13 @@
 package registry
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
 
 	"github.com/narwhl/mockestra"
+	"github.com/narwhl/mockestra/pki"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"go.uber.org/fx"
@@ -24,6 +26,7 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"registry_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"registry"`
+	CA      *pki.CA                              `optional:"true"`
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
@@ -44,9 +47,48 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		}
 	}
 
+	if p.CA != nil {
+		if err := WithCA(p.CA).Customize(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
+// WithCA serves the registry API over HTTPS with a leaf certificate issued by ca.
+// The readiness check is switched to HTTPS and trusts ca.
+func WithCA(ca *pki.CA) testcontainers.CustomizeRequestOption {
+	return func(req *testcontainers.GenericContainerRequest) error {
+		serverCert, err := ca.IssueServer(Tag, pki.ServerNames(req.Name, req.NetworkAliases)...)
+		if err != nil {
+			return fmt.Errorf("failed to issue %s server certificate: %w", ContainerPrettyName, err)
+		}
+		req.Env["REGISTRY_HTTP_TLS_CERTIFICATE"] = "/certs/server.crt"
+		req.Env["REGISTRY_HTTP_TLS_KEY"] = "/certs/server.key"
+		req.Files = append(req.Files,
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.CertPEM),
+				ContainerFilePath: "/certs/server.crt",
+				FileMode:          0o644,
+			},
+			testcontainers.ContainerFile{
+				Reader:            bytes.NewReader(serverCert.KeyPEM),
+				ContainerFilePath: "/certs/server.key",
+				FileMode:          0o600,
+			},
+		)
+		tlsConfig, err := ca.ClientTLSConfig(nil)
+		if err != nil {
+			return err
+		}
+		if strategy, ok := req.WaitingFor.(*wait.HTTPStrategy); ok {
+			strategy.WithTLS(true, tlsConfig)
+		}
+		return nil
+	}
+}
+
 // WithDeleteEnabled enables image deletion in the registry
 func WithDeleteEnabled() testcontainers.CustomizeRequestOption {
 	return func(req *testcontainers.GenericContainerRequest) error {
@@ -0,0 +1,100 @@
+package mockestra
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// SecretSource generates the named secrets modules need, such as cookie
+// and cipher secrets or root credentials. A source is either random, reading
+// crypto/rand like RandomPassword, or deterministic, deriving every secret
+// from a seed with HKDF over the seed and the secret name so that a stack
+// booted twice with the same seed reuses the same values.
+//
+// Modules share the one source of the stack, provided by SecretsModule, so
+// that its Manifest covers every secret the stack was booted with.
+type SecretSource struct {
+	seed []byte
+
+	mu        sync.Mutex
+	generated map[string]string
+}
+
+// RandomSecretSource returns a SecretSource backed by crypto/rand.
+func RandomSecretSource() *SecretSource {
+	return &SecretSource{generated: make(map[string]string)}
+}
+
+// SecretsModule provides the random SecretSource shared by every module of
+// the stack. Seed it with fx.Replace(mockestra.SeededSecretSource("seed")).
+func SecretsModule() fx.Option {
+	return fx.Provide(RandomSecretSource)
+}
+
+// SeededSecretSource returns a SecretSource deriving secrets from seed.
+func SeededSecretSource(seed string) *SecretSource {
+	return &SecretSource{seed: []byte(seed), generated: make(map[string]string)}
+}
+
+// Seeded reports whether the source derives its secrets from a seed.
+func (s *SecretSource) Seeded() bool {
+	return s.seed != nil
+}
+
+// Secret returns the hex encoded secret of length random bytes for name.
+// Asking for the same name twice returns the same value for the lifetime of the source.
+func (s *SecretSource) Secret(name string, length uint) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret, ok := s.generated[name]; ok {
+		return secret, nil
+	}
+	var secret string
+	if s.seed != nil {
+		key, err := hkdf.Key(sha256.New, s.seed, nil, name, int(length))
+		if err != nil {
+			return "", fmt.Errorf("failed to derive secret %s: %w", name, err)
+		}
+		secret = hex.EncodeToString(key)
+	} else {
+		var err error
+		secret, err = RandomPassword(length)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate secret %s: %w", name, err)
+		}
+	}
+	s.generated[name] = secret
+	return secret, nil
+}
+
+// Secrets is the SecretSource counterpart of the package level Secrets function.
+func (s *SecretSource) Secrets(spec map[string]uint) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for name, length := range spec {
+		secret, err := s.Secret(name, length)
+		if err != nil {
+			return nil, err
+		}
+		secrets[name] = secret
+	}
+	return secrets, nil
+}
+
+// Manifest lists every secret handed out so far keyed by name,
+// so tests and tooling can look up the credentials a stack was booted with.
+func (s *SecretSource) Manifest() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.generated)
+}
+
+// SecretName namespaces a module secret, e.g. SecretName("hydra", "cookie") is "hydra.cookie".
+func SecretName(tag, name string) string {
+	return fmt.Sprintf("%s.%s", tag, name)
+}
@@ -0,0 +1,95 @@
+package mockestra_test
+
+import (
+	"testing"
+
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestSeededSecretSource(t *testing.T) {
+	first := mockestra.SeededSecretSource("seed")
+	second := mockestra.SeededSecretSource("seed")
+
+	a, err := first.Secret("hydra.system", 32)
+	if err != nil {
+		t.Fatalf("failed to derive secret: %v", err)
+	}
+	b, err := second.Secret("hydra.system", 32)
+	if err != nil {
+		t.Fatalf("failed to derive secret: %v", err)
+	}
+	if a != b {
+		t.Errorf("expected same seed and name to derive the same secret, got %q and %q", a, b)
+	}
+	if len(a) != 64 {
+		t.Errorf("expected 32 bytes hex encoded, got %d characters", len(a))
+	}
+
+	other, err := first.Secret("hydra.cookie", 32)
+	if err != nil {
+		t.Fatalf("failed to derive secret: %v", err)
+	}
+	if other == a {
+		t.Error("expected different names to derive different secrets")
+	}
+
+	reseeded, err := mockestra.SeededSecretSource("another seed").Secret("hydra.system", 32)
+	if err != nil {
+		t.Fatalf("failed to derive secret: %v", err)
+	}
+	if reseeded == a {
+		t.Error("expected different seeds to derive different secrets")
+	}
+
+	manifest := first.Manifest()
+	if len(manifest) != 2 || manifest["hydra.system"] != a || manifest["hydra.cookie"] != other {
+		t.Errorf("unexpected manifest %v", manifest)
+	}
+}
+
+func TestRandomSecretSource(t *testing.T) {
+	source := mockestra.RandomSecretSource()
+	a, err := source.Secret("versitygw.secret_key", 16)
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+	again, err := source.Secret("versitygw.secret_key", 16)
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+	if a != again {
+		t.Error("expected a source to hand out the same secret for the same name")
+	}
+	b, err := mockestra.RandomSecretSource().Secret("versitygw.secret_key", 16)
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+	if a == b {
+		t.Error("expected independent random sources to generate different secrets")
+	}
+}
+
+func TestSecretsModule(t *testing.T) {
+	var random, seeded *mockestra.SecretSource
+	app := fxtest.New(t, fx.NopLogger, mockestra.SecretsModule(), fx.Populate(&random))
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+	if random == nil || random.Seeded() {
+		t.Errorf("expected a random source, got %v", random)
+	}
+
+	app = fxtest.New(
+		t,
+		fx.NopLogger,
+		mockestra.SecretsModule(),
+		fx.Replace(mockestra.SeededSecretSource("seed")),
+		fx.Populate(&seeded),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+	if seeded == nil || !seeded.Seeded() {
+		t.Errorf("expected the seeded source to replace the random one, got %v", seeded)
+	}
+}
@@ -0,0 +1,47 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go"
+	"go.temporal.io/sdk/client"
+	"go.uber.org/fx"
+)
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Container testcontainers.Container `name:"temporal"`
+}
+
+// NewClient returns a client connected to the default namespace of the container.
+// Namespaces registered through WithNamespace are reachable with
+// client.NewClientFromExisting. The client is closed when the app stops.
+func NewClient(p ClientParams) (client.Client, error) {
+	addr, err := p.Container.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	c, err := client.Dial(client.Options{
+		HostPort: addr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			c.Close()
+			return nil
+		},
+	})
+	return c, nil
+}
+
+// ClientModule provides a client.Client named "temporal" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"temporal"`),
+	),
+)
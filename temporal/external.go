@@ -0,0 +1,34 @@
+package temporal
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// External binds the module to an already running frontend at hostPort
+// instead of launching a container.
+func External(hostPort string) fx.Option {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return fx.Error(fmt.Errorf("invalid external %s address: %w", ContainerPrettyName, err))
+	}
+	return mockestra.External(
+		Tag,
+		&testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Name: host,
+				Env:  make(map[string]string),
+			},
+		},
+		&mockestra.ExternalContainer{
+			ServiceName:  Tag,
+			Address:      host,
+			ServicePorts: map[nat.Port]string{Port: port},
+		},
+	)
+}
@@ -98,3 +98,33 @@ func TestTemporalModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestClientModule(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"temporal_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("temporal-client-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client client.Client `name:"temporal"`
+		}) {
+			if _, err := params.Client.CheckHealth(t.Context(), &client.CheckHealthRequest{}); err != nil {
+				t.Errorf("failed to check temporal health: %v", err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
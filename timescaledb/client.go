@@ -0,0 +1,56 @@
+package timescaledb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// connectionString builds the DSN of the database configured on req, served at addr.
+func connectionString(req *testcontainers.GenericContainerRequest, addr string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s/%s?sslmode=disable",
+		req.Env["POSTGRES_USER"],
+		req.Env["POSTGRES_PASSWORD"],
+		addr,
+		req.Env["POSTGRES_DB"],
+	)
+}
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Request   *testcontainers.GenericContainerRequest `name:"timescaledb"`
+	Container testcontainers.Container                `name:"timescaledb"`
+}
+
+// NewClient returns a connection pool to the database configured on the container.
+// The pool is closed when the app stops.
+func NewClient(p ClientParams) (*pgxpool.Pool, error) {
+	addr, err := p.Container.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	pool, err := pgxpool.New(context.Background(), connectionString(p.Request, addr))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			pool.Close()
+			return nil
+		},
+	})
+	return pool, nil
+}
+
+// ClientModule provides a *pgxpool.Pool named "timescaledb" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"timescaledb"`),
+	),
+)
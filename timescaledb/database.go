@@ -0,0 +1,28 @@
+package timescaledb
+
+import (
+	"cmp"
+
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+type SQLDatabaseParams struct {
+	fx.In
+	Request   *testcontainers.GenericContainerRequest `name:"timescaledb"`
+	Container testcontainers.Container                `name:"timescaledb"`
+}
+
+// NewSQLDatabase returns the server of the container as a mockestra.SQLDatabase,
+// administered with the credentials configured on the request or the image defaults.
+func NewSQLDatabase(p SQLDatabaseParams) mockestra.SQLDatabase {
+	username := cmp.Or(p.Request.Env["POSTGRES_USER"], "postgres")
+	return &mockestra.PostgresDatabase{
+		Container: p.Container,
+		Port:      Port,
+		Username:  username,
+		Password:  p.Request.Env["POSTGRES_PASSWORD"],
+		Database:  cmp.Or(p.Request.Env["POSTGRES_DB"], username),
+	}
+}
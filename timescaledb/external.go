@@ -0,0 +1,49 @@
+package timescaledb
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/jackc/pgx/v5"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// External binds the module to an already running server at dsn instead of
+// launching a container, e.g. timescaledb.External("postgres://user:pass@db:5432/app").
+// The credentials and database of dsn are exposed through the request Env
+// like WithUsername, WithPassword and WithDatabase would.
+func External(dsn string) fx.Option {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return fx.Error(fmt.Errorf("invalid external %s connection string: %w", ContainerPrettyName, err))
+	}
+	return fx.Options(
+		mockestra.External(
+			Tag,
+			&testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{
+					Name: cfg.Host,
+					Env: map[string]string{
+						"POSTGRES_USER":     cfg.User,
+						"POSTGRES_PASSWORD": cfg.Password,
+						"POSTGRES_DB":       cfg.Database,
+					},
+				},
+			},
+			&mockestra.ExternalContainer{
+				ServiceName:  Tag,
+				Address:      cfg.Host,
+				ServicePorts: map[nat.Port]string{Port: strconv.Itoa(int(cfg.Port))},
+			},
+		),
+		fx.Provide(
+			fx.Annotate(
+				NewSQLDatabase,
+				fx.ResultTags(`name:"timescaledb"`),
+			),
+		),
+	)
+}
@@ -0,0 +1,175 @@
+package timescaledb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// RefreshPolicy schedules the refresh of a continuous aggregate over the
+// window from StartOffset to EndOffset before now. A zero StartOffset
+// refreshes from the earliest data, and a zero ScheduleInterval adds no
+// policy, leaving refreshes to RefreshContinuousAggregates.
+type RefreshPolicy struct {
+	StartOffset      time.Duration
+	EndOffset        time.Duration
+	ScheduleInterval time.Duration
+}
+
+// WithHypertable turns table into a hypertable partitioned on timeColumn in
+// chunks of chunkInterval, migrating rows it already holds.
+func WithHypertable(table, timeColumn string, chunkInterval time.Duration) testcontainers.ContainerCustomizer {
+	return withProvisioning(fmt.Sprintf(
+		"SELECT create_hypertable(%s, %s, chunk_time_interval => %s, if_not_exists => true, migrate_data => true)",
+		regclass(table), mockestra.QuoteLiteral(timeColumn), interval(chunkInterval),
+	))
+}
+
+// WithCompressionPolicy compresses the chunks of hypertable once they are
+// older than compressAfter, segmenting compressed rows by segmentBy columns.
+func WithCompressionPolicy(hypertable string, compressAfter time.Duration, segmentBy ...string) testcontainers.ContainerCustomizer {
+	compress := "timescaledb.compress"
+	if len(segmentBy) > 0 {
+		columns := make([]string, len(segmentBy))
+		for i, column := range segmentBy {
+			columns[i] = pgx.Identifier{column}.Sanitize()
+		}
+		compress += ", timescaledb.compress_segmentby = " + mockestra.QuoteLiteral(strings.Join(columns, ", "))
+	}
+	return withProvisioning(
+		fmt.Sprintf("ALTER TABLE %s SET (%s)", identifier(hypertable), compress),
+		fmt.Sprintf("SELECT add_compression_policy(%s, %s, if_not_exists => true)", regclass(hypertable), interval(compressAfter)),
+	)
+}
+
+// WithRetentionPolicy drops the chunks of hypertable once they are older than dropAfter.
+func WithRetentionPolicy(hypertable string, dropAfter time.Duration) testcontainers.ContainerCustomizer {
+	return withProvisioning(fmt.Sprintf(
+		"SELECT add_retention_policy(%s, %s, if_not_exists => true)",
+		regclass(hypertable), interval(dropAfter),
+	))
+}
+
+// WithContinuousAggregate creates the continuous aggregate name over query,
+// such as "SELECT time_bucket('1 hour', time) AS bucket, avg(value) FROM
+// metrics GROUP BY bucket", refreshed according to refresh.
+func WithContinuousAggregate(name, query string, refresh RefreshPolicy) testcontainers.ContainerCustomizer {
+	statements := []string{fmt.Sprintf(
+		"CREATE MATERIALIZED VIEW IF NOT EXISTS %s WITH (timescaledb.continuous) AS %s WITH NO DATA",
+		identifier(name), strings.TrimSuffix(strings.TrimSpace(query), ";"),
+	)}
+	if refresh.ScheduleInterval > 0 {
+		start := "NULL"
+		if refresh.StartOffset > 0 {
+			start = interval(refresh.StartOffset)
+		}
+		statements = append(statements, fmt.Sprintf(
+			"SELECT add_continuous_aggregate_policy(%s, start_offset => %s, end_offset => %s, schedule_interval => %s, if_not_exists => true)",
+			regclass(name), start, interval(refresh.EndOffset), interval(refresh.ScheduleInterval),
+		))
+	}
+	return withProvisioning(statements...)
+}
+
+// provisioningOption carries the statements of a provisioning option, which
+// New collects from the options of the module in the order they are given and
+// runs once the PostReady hooks of every option, such as migrations, ran.
+type provisioningOption []string
+
+// Customize leaves the request as is, the statements being run by New.
+func (provisioningOption) Customize(*testcontainers.GenericContainerRequest) error {
+	return nil
+}
+
+func withProvisioning(statements ...string) testcontainers.ContainerCustomizer {
+	return provisioningOption(statements)
+}
+
+// provisioning returns the statements of the provisioning options of opts in order.
+func provisioning(opts []testcontainers.ContainerCustomizer) []string {
+	var statements []string
+	for _, opt := range opts {
+		if option, ok := opt.(provisioningOption); ok {
+			statements = append(statements, option...)
+		}
+	}
+	return statements
+}
+
+// provision runs the statements of the provisioning options one by one, as
+// continuous aggregates cannot be created in a transaction.
+func provision(ctx context.Context, dsn string, statements []string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	defer conn.Close(ctx)
+	for _, statement := range statements {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to provision %s with %q: %w", ContainerPrettyName, statement, err)
+		}
+	}
+	slog.Info(fmt.Sprintf("%s provisioned", ContainerPrettyName), "statements", len(statements))
+	return nil
+}
+
+// Querier runs statements, satisfied by *pgx.Conn and *pgxpool.Pool.
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// RefreshContinuousAggregates refreshes the continuous aggregates names over
+// all of their data, so that tests see inserted rows without waiting for
+// refresh policies.
+func RefreshContinuousAggregates(ctx context.Context, q Querier, names ...string) error {
+	for _, name := range names {
+		// procedures managing their own transactions are only called through the simple protocol
+		if _, err := q.Exec(ctx, fmt.Sprintf("CALL refresh_continuous_aggregate(%s, NULL, NULL)", regclass(name))); err != nil {
+			return fmt.Errorf("failed to refresh continuous aggregate %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// RunJobs runs every scheduled job, such as compression, retention and
+// refresh policies, synchronously in the order they were added, instead of
+// waiting for their schedule.
+func RunJobs(ctx context.Context, q Querier) error {
+	rows, err := q.Query(ctx, "SELECT job_id FROM timescaledb_information.jobs WHERE job_id >= 1000 ORDER BY job_id")
+	if err != nil {
+		return fmt.Errorf("failed to list %s jobs: %w", ContainerPrettyName, err)
+	}
+	jobs, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if err != nil {
+		return fmt.Errorf("failed to list %s jobs: %w", ContainerPrettyName, err)
+	}
+	for _, job := range jobs {
+		if _, err := q.Exec(ctx, fmt.Sprintf("CALL run_job(%d)", job)); err != nil {
+			return fmt.Errorf("failed to run %s job %d: %w", ContainerPrettyName, job, err)
+		}
+	}
+	return nil
+}
+
+// identifier quotes a possibly schema qualified name such as "metrics.cpu".
+func identifier(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
+
+// regclass quotes name as the regclass literal TimescaleDB functions take.
+func regclass(name string) string {
+	return mockestra.QuoteLiteral(identifier(name))
+}
+
+// interval renders d as an interval literal.
+func interval(d time.Duration) string {
+	return fmt.Sprintf("INTERVAL %s", mockestra.QuoteLiteral(fmt.Sprintf("%d microseconds", d.Microseconds())))
+}
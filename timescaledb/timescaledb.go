@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/narwhl/mockestra"
+	mockpostgres "github.com/narwhl/mockestra/postgres"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"go.uber.org/fx"
@@ -23,6 +24,15 @@ var (
 	WithUsername = postgres.WithUsername
 	WithPassword = postgres.WithPassword
 	WithDatabase = postgres.WithDatabase
+
+	// WithEphemeralTuning applies the Postgres tuning preset, which the
+	// TimescaleDB image shares along with its entrypoint.
+	WithEphemeralTuning = mockpostgres.WithEphemeralTuning
+
+	// WithMigrationsFS and WithSeedFS are the Postgres ones, the image
+	// sharing its port and environment.
+	WithMigrationsFS = mockpostgres.WithMigrationsFS
+	WithSeedFS       = mockpostgres.WithSeedFS
 )
 
 type migration func(string) error
@@ -36,15 +46,7 @@ func WithMigration(fn migration) testcontainers.CustomizeRequestOption {
 					if err != nil {
 						return fmt.Errorf("encounter error getting addr while running migration: %w", err)
 					}
-					return fn(
-						fmt.Sprintf(
-							"postgres://%s:%s@%s/%s?sslmode=disable",
-							req.Env["POSTGRES_USER"],
-							req.Env["POSTGRES_PASSWORD"],
-							addr,
-							req.Env["POSTGRES_DB"],
-						),
-					)
+					return fn(connectionString(req, addr))
 				},
 			},
 		})
@@ -80,6 +82,15 @@ func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
 		}
 	}
 
+	// provisioning runs last, over the tables created by migrations
+	if statements := provisioning(p.Opts); len(statements) > 0 {
+		if err := mockpostgres.WithConnection("provisioning", func(ctx context.Context, dsn string) error {
+			return provision(ctx, dsn, statements)
+		})(&r); err != nil {
+			return nil, err
+		}
+	}
+
 	return &r, nil
 }
 
@@ -139,5 +150,9 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"timescaledb"`),
 		),
 		Actualize,
+		fx.Annotate(
+			NewSQLDatabase,
+			fx.ResultTags(`name:"timescaledb"`),
+		),
 	),
 )
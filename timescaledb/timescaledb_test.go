@@ -3,10 +3,12 @@ package timescaledb_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	container "github.com/narwhl/mockestra/timescaledb"
 	"github.com/testcontainers/testcontainers-go"
 	"go.uber.org/fx"
@@ -133,3 +135,96 @@ func TestTimescaleDBModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestProvisioningOrder(t *testing.T) {
+	var migrated bool
+	req, err := container.New(container.RequestParams{
+		Prefix:  "timescaledb-provisioning-order",
+		Version: "latest-pg17",
+		Opts: []testcontainers.ContainerCustomizer{
+			container.WithHypertable("metrics", "time", 24*time.Hour),
+			container.WithMigration(func(string) error {
+				migrated = true
+				return nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if len(req.LifecycleHooks) != 2 {
+		t.Fatalf("expected migration and provisioning hooks, got %d", len(req.LifecycleHooks))
+	}
+	for label, value := range req.Labels {
+		if strings.Contains(value, "create_hypertable") {
+			t.Errorf("expected provisioning statements to stay out of the labels, got %s=%q", label, value)
+		}
+	}
+	if err := req.LifecycleHooks[0].PostReadies[0](context.Background(), &mockContainer{endpoint: "localhost:5432"}); err != nil {
+		t.Fatalf("PostReady hook failed: %v", err)
+	}
+	if !migrated {
+		t.Error("expected migrations to run ahead of provisioning")
+	}
+}
+
+func TestProvisioning(t *testing.T) {
+	var pool *pgxpool.Pool
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"latest-pg17",
+				fx.ResultTags(`name:"timescaledb_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("timescaledb-provisioning-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		container.Module(
+			container.WithUsername("testuser"),
+			container.WithPassword("testpass"),
+			container.WithDatabase("testdb"),
+			container.WithHypertable("metrics", "time", 24*time.Hour),
+			container.WithCompressionPolicy("metrics", 7*24*time.Hour, "device"),
+			container.WithRetentionPolicy("metrics", 90*24*time.Hour),
+			container.WithContinuousAggregate(
+				"metrics_hourly",
+				"SELECT time_bucket('1 hour', time) AS bucket, device, avg(value) AS value FROM metrics GROUP BY bucket, device",
+				container.RefreshPolicy{EndOffset: time.Hour, ScheduleInterval: time.Hour},
+			),
+			container.WithMigration(func(dsn string) error {
+				conn, err := pgx.Connect(context.Background(), dsn)
+				if err != nil {
+					return err
+				}
+				defer conn.Close(context.Background())
+				_, err = conn.Exec(context.Background(), "CREATE TABLE metrics (time TIMESTAMPTZ NOT NULL, device TEXT, value DOUBLE PRECISION)")
+				return err
+			}),
+		),
+		container.ClientModule,
+		fx.Populate(fx.Annotate(&pool, fx.ParamTags(`name:"timescaledb"`))),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	if _, err := pool.Exec(t.Context(), "INSERT INTO metrics VALUES (now() - INTERVAL '3 hours', 'a', 1), (now() - INTERVAL '3 hours', 'a', 3)"); err != nil {
+		t.Fatalf("failed to insert metrics: %v", err)
+	}
+	if err := container.RefreshContinuousAggregates(t.Context(), pool, "metrics_hourly"); err != nil {
+		t.Fatalf("failed to refresh aggregates: %v", err)
+	}
+	var value float64
+	if err := pool.QueryRow(t.Context(), "SELECT value FROM metrics_hourly WHERE device = 'a'").Scan(&value); err != nil {
+		t.Fatalf("failed to query aggregate: %v", err)
+	}
+	if value != 2 {
+		t.Errorf("expected average of 2, got %v", value)
+	}
+	if err := container.RunJobs(t.Context(), pool); err != nil {
+		t.Errorf("failed to run jobs: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package mockestra
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+// Requirement reports, for a module included in the stack, the named values
+// it needs that are absent from the graph. It is provided by every module
+// built with BuildContainerModule into the `group:"requirements"` value group.
+type Requirement struct {
+	Module  string
+	Missing []string
+}
+
+// Dependency declares that a module consumes the containers of other modules.
+type Dependency struct {
+	Module string
+	On     []string
+}
+
+// DependsOn declares that module needs the containers of modules,
+// e.g. mockestra.DependsOn(kratos.Tag, hydra.Tag, mailslurper.Tag, postgres.Tag).
+func DependsOn(module string, modules ...string) fx.Option {
+	return fx.Supply(
+		fx.Annotate(
+			Dependency{Module: module, On: modules},
+			fx.ResultTags(`group:"dependencies"`),
+		),
+	)
+}
+
+// requirement builds the provider checking the prefix and version of module.
+func requirement(module string) any {
+	return fx.Annotate(
+		func(prefix, version string) Requirement {
+			r := Requirement{Module: module}
+			if prefix == "" {
+				r.Missing = append(r.Missing, "prefix")
+			}
+			if version == "" {
+				r.Missing = append(r.Missing, fmt.Sprintf("%s_version", module))
+			}
+			return r
+		},
+		fx.ParamTags(`name:"prefix" optional:"true"`, fmt.Sprintf(`name:"%s_version" optional:"true"`, module)),
+		fx.ResultTags(`group:"requirements"`),
+	)
+}
+
+// ValidationError aggregates every problem found in a stack by Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("stack is missing %d requirement(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks that every module has its named values and that the
+// modules it depends on are included, returning a *ValidationError listing
+// every problem at once.
+func Validate(requirements []Requirement, dependencies []Dependency) error {
+	var included []string
+	for _, r := range requirements {
+		included = append(included, r.Module)
+	}
+
+	var problems []string
+	for _, r := range requirements {
+		for _, name := range r.Missing {
+			problems = append(problems, fmt.Sprintf("%s: named value %q is not supplied, supply it with fx.Supply or mockestra.Versions", r.Module, name))
+		}
+	}
+	for _, d := range dependencies {
+		if !slices.Contains(included, d.Module) {
+			continue
+		}
+		for _, module := range d.On {
+			if !slices.Contains(included, module) {
+				problems = append(problems, fmt.Sprintf("%s: depends on module %s which is not included, add %s.Module()", d.Module, module, module))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	slices.Sort(problems)
+	return &ValidationError{Problems: slices.Compact(problems)}
+}
+
+type ValidateParams struct {
+	fx.In
+	Requirements []Requirement `group:"requirements"`
+	Dependencies []Dependency  `group:"dependencies"`
+}
+
+// ValidateModule runs Validate over every module of the stack, so that a
+// forgotten prefix or version surfaces as one readable error instead of a
+// missing dependency deep in the graph. It starts no container, so it has to
+// be placed before any fx.Invoke that consumes containers.
+func ValidateModule() fx.Option {
+	return fx.Invoke(func(p ValidateParams) error {
+		return Validate(p.Requirements, p.Dependencies)
+	})
+}
+
+// DefaultVersions returns the pinned image versions the module test suites
+// run against, keyed by module tag, to be passed to Versions. Mailslurper
+// only publishes the latest-smtps tag, which can be pinned to a digest with
+// LockModule. The returned map is a fresh copy and can be amended before use.
+func DefaultVersions() map[string]string {
+	return map[string]string{
+		"concourse":   "7.14.0",
+		"dind":        "27.5.1",
+		"hydra":       "v2.3.0",
+		"kanidm":      "1.7.0",
+		"kratos":      "v1.3.1",
+		"lgtm":        "0.11.0",
+		"livekit":     "v1.10.1",
+		"mailslurper": "latest-smtps",
+		"minio":       "RELEASE.2025-04-22T22-12-26Z",
+		"nats":        "2.11.4",
+		"openfga":     "v1.8.9",
+		"pgbouncer":   "v1.24.1-p1",
+		"postgres":    "17.6",
+		"redis":       "8.0.2-alpine",
+		"registry":    "2.8.3",
+		"rustfs":      "1.0.0-alpha.58",
+		"temporal":    "1.27.2",
+		"timescaledb": "2.19.3-pg17",
+		"typesense":   "29.0",
+		"valkey":      "8.1.1-alpine",
+		"versitygw":   "v1.0.10",
+		"zitadel":     "v4.0.0",
+	}
+}
@@ -0,0 +1,81 @@
+package mockestra_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// testModule builds a module whose request needs the prefix and version like the real ones.
+func testModule(tag string, dependencies ...string) fx.Option {
+	return mockestra.BuildContainerModule(
+		tag,
+		fx.Provide(
+			fx.Annotate(
+				func(prefix, version string) *testcontainers.GenericContainerRequest {
+					return &testcontainers.GenericContainerRequest{
+						ContainerRequest: testcontainers.ContainerRequest{Image: tag + ":" + version},
+					}
+				},
+				fx.ParamTags(`name:"prefix"`, `name:"`+tag+`_version"`),
+				fx.ResultTags(`name:"`+tag+`"`),
+			),
+		),
+		mockestra.DependsOn(tag, dependencies...),
+	)()
+}
+
+func TestValidateModule(t *testing.T) {
+	err := fx.New(
+		fx.NopLogger,
+		fx.Supply(fx.Annotate("validate-test", fx.ResultTags(`name:"prefix"`))),
+		fx.Options(mockestra.Versions(map[string]string{"first": "1"})...),
+		testModule("first"),
+		testModule("second", "first", "third"),
+		mockestra.ValidateModule(),
+	).Err()
+
+	var validationErr *mockestra.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	expected := []string{
+		`second: depends on module third which is not included, add third.Module()`,
+		`second: named value "second_version" is not supplied, supply it with fx.Supply or mockestra.Versions`,
+	}
+	if !slices.Equal(validationErr.Problems, expected) {
+		t.Errorf("unexpected problems:\n%v\nexpected:\n%v", validationErr.Problems, expected)
+	}
+}
+
+func TestValidateModuleDefaultVersions(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(fx.Annotate("validate-test", fx.ResultTags(`name:"prefix"`))),
+		fx.Options(mockestra.Versions(mockestra.DefaultVersions())...),
+		testModule("postgres"),
+		testModule("hydra", "postgres"),
+		mockestra.ValidateModule(),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
+
+func TestDefaultVersionsArePinned(t *testing.T) {
+	for tag, version := range mockestra.DefaultVersions() {
+		// mailslurper publishes no other tag
+		if tag == "mailslurper" {
+			continue
+		}
+		if strings.HasPrefix(version, "latest") {
+			t.Errorf("expected a pinned %s version, got %s", tag, version)
+		}
+	}
+}
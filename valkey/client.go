@@ -0,0 +1,49 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go"
+	valkeygo "github.com/valkey-io/valkey-go"
+	"go.uber.org/fx"
+)
+
+type ClientParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Container testcontainers.Container `name:"valkey"`
+	InProcess bool                     `name:"in_process" optional:"true"`
+}
+
+// NewClient returns a client connected to the container. Client side
+// caching is disabled for the in-process fake, which does not support it.
+// The client is closed when the app stops.
+func NewClient(p ClientParams) (valkeygo.Client, error) {
+	addr, err := p.Container.PortEndpoint(context.Background(), Port, "")
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while querying %s container endpoint: %w", ContainerPrettyName, err)
+	}
+	client, err := valkeygo.NewClient(valkeygo.ClientOption{
+		InitAddress:  []string{addr},
+		DisableCache: p.InProcess,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while connecting to %s: %w", ContainerPrettyName, err)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			client.Close()
+			return nil
+		},
+	})
+	return client, nil
+}
+
+// ClientModule provides a valkey.Client named "valkey" connected to the container.
+var ClientModule = fx.Provide(
+	fx.Annotate(
+		NewClient,
+		fx.ResultTags(`name:"valkey"`),
+	),
+)
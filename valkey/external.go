@@ -0,0 +1,34 @@
+package valkey
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/narwhl/mockestra"
+	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/fx"
+)
+
+// External binds the module to an already running server at addr, given as
+// host:port, instead of launching a container.
+func External(addr string) fx.Option {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fx.Error(fmt.Errorf("invalid external %s address: %w", ContainerPrettyName, err))
+	}
+	return mockestra.External(
+		Tag,
+		&testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Name: host,
+				Env:  make(map[string]string),
+			},
+		},
+		&mockestra.ExternalContainer{
+			ServiceName:  Tag,
+			Address:      host,
+			ServicePorts: map[nat.Port]string{Port: port},
+		},
+	)
+}
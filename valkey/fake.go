@@ -0,0 +1,43 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+)
+
+// actualizeFake serves the module from an in-process miniredis instead of
+// a container, for stacks switched with mockestra.InProcess.
+func actualizeFake(p ContainerParams) (Result, error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return Result{}, fmt.Errorf("an error occurred while starting in-process %s: %w", ContainerPrettyName, err)
+	}
+	c, err := mockestra.FakeContainer(Tag, server.Addr(), Port)
+	if err == nil {
+		err = mockestra.RunPostReadyHooks(context.Background(), p.Request, c)
+	}
+	if err != nil {
+		server.Close()
+		return Result{}, err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			slog.Info(fmt.Sprintf("%s in-process fake is running at", ContainerPrettyName), "addr", server.Addr())
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			server.Close()
+			slog.Info(fmt.Sprintf("%s in-process fake is stopped", ContainerPrettyName))
+			return nil
+		},
+	})
+	return Result{
+		Container:      c,
+		ContainerGroup: c,
+	}, nil
+}
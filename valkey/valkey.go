@@ -55,6 +55,7 @@ type ContainerParams struct {
 	fx.In
 	Lifecycle fx.Lifecycle
 	Request   *testcontainers.GenericContainerRequest `name:"valkey"`
+	InProcess bool                                    `name:"in_process" optional:"true"`
 }
 
 type Result struct {
@@ -64,6 +65,9 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
+	if p.InProcess {
+		return actualizeFake(p)
+	}
 	c, err := testcontainers.GenericContainer(context.Background(), *p.Request)
 	if err != nil {
 		return Result{}, fmt.Errorf("an error occurred while instantiating %s container: %w", ContainerPrettyName, err)
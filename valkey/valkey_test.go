@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/narwhl/mockestra"
 	container "github.com/narwhl/mockestra/valkey"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/valkey-io/valkey-go"
@@ -46,3 +47,37 @@ func TestValKeyModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestInProcess(t *testing.T) {
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				"8-alpine",
+				fx.ResultTags(`name:"valkey_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("valkey-inprocess-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		mockestra.InProcess(),
+		container.Module(),
+		container.ClientModule,
+		fx.Invoke(func(params struct {
+			fx.In
+			Client valkey.Client `name:"valkey"`
+		}) {
+			if err := params.Client.Do(t.Context(), params.Client.B().Set().Key("key").Value("value").Build()).Error(); err != nil {
+				t.Errorf("failed to set key: %v", err)
+			}
+			if value, err := params.Client.Do(t.Context(), params.Client.B().Get().Key("key").Build()).ToString(); err != nil || value != "value" {
+				t.Errorf("expected value, got %q, %v", value, err)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
@@ -0,0 +1,65 @@
+package mockestra
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+// VersionsFromEnv reads image versions from environment variables named
+// {prefix}{TAG}_VERSION, e.g. MOCKESTRA_POSTGRES_VERSION=17-alpine with
+// prefix "MOCKESTRA_", and returns them keyed by module tag.
+func VersionsFromEnv(prefix string) map[string]string {
+	versions := make(map[string]string)
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "_VERSION") || value == "" {
+			continue
+		}
+		tag := strings.TrimSuffix(strings.TrimPrefix(key, prefix), "_VERSION")
+		if tag == "" {
+			continue
+		}
+		versions[strings.ToLower(tag)] = value
+	}
+	return versions
+}
+
+// VersionsFromFile reads image versions from a JSON object keyed by module tag,
+// e.g. {"postgres": "16-alpine", "valkey": "7-alpine"}.
+func VersionsFromFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions file %s: %w", path, err)
+	}
+	versions := make(map[string]string)
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse versions file %s: %w", path, err)
+	}
+	return versions, nil
+}
+
+// ResolveVersions merges sets of image versions, later sets overriding
+// earlier ones, and supplies the result like Versions. The resolved set is
+// logged once when the stack is built. The usual order is defaults, file, env:
+//
+//	mockestra.ResolveVersions(mockestra.DefaultVersions(), fromFile, mockestra.VersionsFromEnv("MOCKESTRA_"))
+func ResolveVersions(sets ...map[string]string) []fx.Option {
+	resolved := make(map[string]string)
+	for _, set := range sets {
+		maps.Copy(resolved, set)
+	}
+	var attrs []any
+	for _, tag := range slices.Sorted(maps.Keys(resolved)) {
+		attrs = append(attrs, tag, resolved[tag])
+	}
+	return append(Versions(resolved), fx.Invoke(func() {
+		slog.Info("Resolved image versions", attrs...)
+	}))
+}
@@ -0,0 +1,70 @@
+package mockestra_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/narwhl/mockestra"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestVersionsFromEnv(t *testing.T) {
+	t.Setenv("MOCKESTRA_POSTGRES_VERSION", "15-alpine")
+	t.Setenv("MOCKESTRA_VALKEY_VERSION", "7-alpine")
+	t.Setenv("MOCKESTRA_EMPTY_VERSION", "")
+	t.Setenv("OTHER_REDIS_VERSION", "6")
+
+	versions := mockestra.VersionsFromEnv("MOCKESTRA_")
+	if len(versions) != 2 || versions["postgres"] != "15-alpine" || versions["valkey"] != "7-alpine" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+}
+
+func TestVersionsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+	if err := os.WriteFile(path, []byte(`{"postgres": "16-alpine"}`), 0o644); err != nil {
+		t.Fatalf("failed to write versions file: %v", err)
+	}
+	versions, err := mockestra.VersionsFromFile(path)
+	if err != nil {
+		t.Fatalf("VersionsFromFile failed: %v", err)
+	}
+	if versions["postgres"] != "16-alpine" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+
+	if _, err := mockestra.VersionsFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected missing file to fail")
+	}
+}
+
+type resolvedVersions struct {
+	fx.In
+	Postgres string `name:"postgres_version"`
+	Redis    string `name:"redis_version"`
+	Valkey   string `name:"valkey_version"`
+}
+
+func TestResolveVersions(t *testing.T) {
+	var p resolvedVersions
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		fx.Options(mockestra.ResolveVersions(
+			map[string]string{"postgres": "17-alpine", "redis": "8-alpine", "valkey": "8-alpine"},
+			map[string]string{"postgres": "16-alpine", "valkey": "7-alpine"},
+			map[string]string{"postgres": "15-alpine"},
+		)...),
+		fx.Invoke(func(in resolvedVersions) {
+			p = in
+		}),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+
+	if p.Postgres != "15-alpine" || p.Redis != "8-alpine" || p.Valkey != "7-alpine" {
+		t.Errorf("unexpected resolved versions postgres=%s redis=%s valkey=%s", p.Postgres, p.Redis, p.Valkey)
+	}
+}
@@ -108,15 +108,16 @@ type RequestParams struct {
 	Prefix  string                               `name:"prefix"`
 	Version string                               `name:"versitygw_version"`
 	Opts    []testcontainers.ContainerCustomizer `group:"versitygw"`
+	Secrets *mockestra.SecretSource
 }
 
 func New(p RequestParams) (*testcontainers.GenericContainerRequest, error) {
-	// Generate random credentials for S3 API access
-	accessKey, err := mockestra.RandomPassword(16)
+	// Generate credentials for S3 API access
+	accessKey, err := p.Secrets.Secret(mockestra.SecretName(Tag, "access_key"), 16)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access key: %w", err)
 	}
-	secretKey, err := mockestra.RandomPassword(32)
+	secretKey, err := p.Secrets.Secret(mockestra.SecretName(Tag, "secret_key"), 32)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate secret key: %w", err)
 	}
@@ -152,6 +153,7 @@ type ContainerParams struct {
 	fx.In
 	Lifecycle fx.Lifecycle
 	Request   *testcontainers.GenericContainerRequest `name:"versitygw"`
+	InProcess bool                                    `name:"in_process" optional:"true"`
 }
 
 type Result struct {
@@ -161,6 +163,9 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
+	if p.InProcess {
+		return actualizeFake(p)
+	}
 	c, err := testcontainers.GenericContainer(context.Background(), *p.Request)
 	if err != nil {
 		return Result{}, fmt.Errorf("an error occurred while instantiating %s container: %w", ContainerPrettyName, err)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/narwhl/mockestra"
 	container "github.com/narwhl/mockestra/versitygw"
 	"github.com/testcontainers/testcontainers-go"
 	"go.uber.org/fx"
@@ -111,6 +112,7 @@ func TestVersityGWModule_WithBucket(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -167,6 +169,7 @@ func TestVersityGWModule(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 		fx.Supply(
 			fx.Annotate(
 				"latest",
@@ -213,3 +216,62 @@ func TestVersityGWModule(t *testing.T) {
 	app.RequireStart()
 	t.Cleanup(app.RequireStop)
 }
+
+func TestVersityGWModule_InProcess(t *testing.T) {
+	expectedBucket := "test-bucket"
+	app := fxtest.New(
+		t,
+		fx.NopLogger,
+		mockestra.SecretsModule(),
+		fx.Supply(
+			fx.Annotate(
+				"latest",
+				fx.ResultTags(`name:"versitygw_version"`),
+			),
+		),
+		fx.Supply(fx.Annotate(
+			fmt.Sprintf("versitygw-inprocess-test-%x", time.Now().Unix()),
+			fx.ResultTags(`name:"prefix"`),
+		)),
+		mockestra.InProcess(),
+		container.Module(
+			container.WithAccessKey("testuser"),
+			container.WithSecretKey("testsecret"),
+			container.WithBucket(expectedBucket),
+		),
+		fx.Invoke(func(params struct {
+			fx.In
+			Container testcontainers.Container `name:"versitygw"`
+		}) {
+			endpoint, err := params.Container.PortEndpoint(t.Context(), container.Port, "")
+			if err != nil {
+				t.Errorf("failed to get endpoint: %v", err)
+			}
+			client, err := minio.New(endpoint, &minio.Options{
+				Creds:  credentials.NewStaticV4("testuser", "testsecret", ""),
+				Secure: false,
+			})
+			if err != nil {
+				t.Errorf("failed to create minio client: %v", err)
+			}
+			buckets, err := client.ListBuckets(t.Context())
+			if err != nil {
+				t.Errorf("failed to list buckets: %v", err)
+				return
+			}
+			found := false
+			for _, bucket := range buckets {
+				if bucket.Name == expectedBucket {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected bucket '%s' to be created, got: %v", expectedBucket, buckets)
+			}
+		}),
+	)
+
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+}
@@ -10,7 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/docker/go-connections/nat"
 	"github.com/narwhl/mockestra"
 	"github.com/narwhl/mockestra/postgres"
 	"github.com/testcontainers/testcontainers-go"
@@ -143,10 +142,10 @@ func WithServiceUser(username string, machineKeyPath string) testcontainers.Cust
 
 type ContainerParams struct {
 	fx.In
-	Lifecycle                fx.Lifecycle
-	PostgresContainerRequest *testcontainers.GenericContainerRequest `name:"postgres"`
-	PostgresContainer        testcontainers.Container                `name:"postgres"`
-	Request                  *testcontainers.GenericContainerRequest `name:"zitadel"`
+	Lifecycle fx.Lifecycle
+	Database  mockestra.SQLDatabase                   `name:"postgres"`
+	Request   *testcontainers.GenericContainerRequest `name:"zitadel"`
+	Secrets   *mockestra.SecretSource
 }
 
 type Result struct {
@@ -156,24 +155,29 @@ type Result struct {
 }
 
 func Actualize(p ContainerParams) (Result, error) {
-	postgresIP, err := p.PostgresContainer.ContainerIP(context.Background())
+	postgresHost, postgresPort, err := p.Database.Address(context.Background())
 	if err != nil {
-		return Result{}, fmt.Errorf("failed to get %s container IP: %w", postgres.ContainerPrettyName, err)
+		return Result{}, fmt.Errorf("failed to get database address: %w", err)
 	}
-	_, postgresPort := nat.SplitProtoPort(postgres.Port)
+	postgresUser, postgresPassword, err := mockestra.ProvisionDatabase(context.Background(), p.Database, p.Secrets, Tag, DatabaseName)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to provision zitadel database: %w", err)
+	}
+	// zitadel initializes its schema through the admin connection
+	postgresAdminUser, postgresAdminPassword := p.Database.AdminCredentials()
 	if err := WithPostgresConnection(
-		postgresIP,
+		postgresHost,
 		postgresPort,
 		DatabaseName,
-		p.PostgresContainerRequest.Env["POSTGRES_USER"], // TODO: use database specific user instead of admin user
-		p.PostgresContainerRequest.Env["POSTGRES_PASSWORD"],
+		postgresUser,
+		postgresPassword,
 		"disable",
 	).Customize(p.Request); err != nil {
 		return Result{}, fmt.Errorf("failed to apply zitadel postgres connection: %w", err)
 	}
 	if err := WithPostgresAdminConnection(
-		p.PostgresContainerRequest.Env["POSTGRES_USER"],
-		p.PostgresContainerRequest.Env["POSTGRES_PASSWORD"],
+		postgresAdminUser,
+		postgresAdminPassword,
 		"disable",
 	).Customize(p.Request); err != nil {
 		return Result{}, fmt.Errorf("failed to apply zitadel postgres admin connection: %w", err)
@@ -224,4 +228,5 @@ var Module = mockestra.BuildContainerModule(
 			fx.ResultTags(`name:"zitadel"`),
 		),
 	),
+	mockestra.DependsOn(Tag, postgres.Tag),
 )
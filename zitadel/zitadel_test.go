@@ -30,6 +30,7 @@ func TestZitadelWithPostgres(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		// Supply required versions using fx.Supply with fx.Annotate and fx.ResultTags decorators
 		fx.Supply(
@@ -118,6 +119,7 @@ func TestZitadelWithPostReadyHook(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		// Supply versions
 		fx.Supply(
@@ -265,6 +267,7 @@ func TestZitadelServiceUserConfiguration(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		fx.Supply(
 			fx.Annotate("16", fx.ResultTags(`name:"postgres_version"`)),
@@ -361,6 +364,7 @@ func TestZitadelFxDecoratorFunctions(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		// Test fx.Supply with fx.Annotate and fx.ResultTags decorators
 		fx.Supply(
@@ -431,6 +435,7 @@ func TestZitadelOIDCFunctionality(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		fx.Supply(
 			fx.Annotate("16", fx.ResultTags(`name:"postgres_version"`)),
@@ -520,6 +525,7 @@ func TestZitadelProvisioningFunctionality(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		fx.Supply(
 			fx.Annotate("16", fx.ResultTags(`name:"postgres_version"`)),
@@ -612,6 +618,7 @@ func TestZitadelEndToEndIntegration(t *testing.T) {
 	app := fxtest.New(
 		t,
 		fx.NopLogger,
+		mockestra.SecretsModule(),
 
 		fx.Supply(
 			fx.Annotate("16", fx.ResultTags(`name:"postgres_version"`)),